@@ -0,0 +1,355 @@
+package pop
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// sqlScanner finds the `?` placeholders of a SQL statement, skipping quoted
+// literals, quoted identifiers and comments.
+type sqlScanner struct {
+	// backslashEscapes is set for the dialects which escape quotes with a
+	// backslash in string literals, such as MySQL.
+	backslashEscapes bool
+	// nativeArrays is set for the dialects which can bind a slice as an
+	// array, such as PostgreSQL.
+	nativeArrays bool
+}
+
+func newSQLScanner(d dialect) sqlScanner {
+	switch d.Name() {
+	case nameMySQL, nameMariaDB:
+		return sqlScanner{backslashEscapes: true}
+	case namePostgreSQL, nameCockroach:
+		return sqlScanner{nativeArrays: true}
+	}
+	return sqlScanner{}
+}
+
+var (
+	inArgRegex    = regexp.MustCompile(`(?i)\b(not\s+)?in\s*\(\s*$`)
+	closingRegex  = regexp.MustCompile(`^\s*\)`)
+	arrayArgRegex = regexp.MustCompile(`(?i)\b(any|all)\s*\(\s*$`)
+)
+
+// bind binds args to the placeholders of query. Slices are expanded into
+// one placeholder per element, or bound as an array when passed to
+// `ANY(?)` or `ALL(?)` and the dialect supports it. An empty slice bound to
+// `x IN (?)` turns the predicate into an always false one, and into an
+// always true one for `x NOT IN (?)`. The operand x may be a column or an
+// expression such as `lower(name)` or `(a, b)`.
+//
+// The query is returned unchanged if no arguments are given, or if it
+// uses no `?` placeholders, e.g. `$1` on PostgreSQL.
+func (s sqlScanner) bind(query string, args []interface{}) (string, []interface{}, error) {
+	if len(args) == 0 {
+		return query, args, nil
+	}
+
+	out := make([]byte, 0, len(query))
+	bound := make([]interface{}, 0, len(args))
+	n, start := 0, 0
+	for {
+		i := s.next(query, start)
+		if i < 0 {
+			break
+		}
+		out = append(out, query[start:i]...)
+		start = i + 1
+		if n >= len(args) {
+			return query, args, fmt.Errorf("missing argument for placeholder %d in %q", n+1, query)
+		}
+		arg := args[n]
+		n++
+
+		rv, ok := expandable(arg)
+		switch {
+		case !ok:
+			out = append(out, '?')
+			bound = append(bound, arg)
+		case s.nativeArrays && arrayArgRegex.Match(out):
+			out = append(out, '?')
+			bound = append(bound, pq.Array(arg))
+		case rv.Len() == 0:
+			loc := inArgRegex.FindSubmatchIndex(out)
+			closing := closingRegex.FindString(query[start:])
+			if loc == nil || closing == "" {
+				return query, args, fmt.Errorf("cannot bind an empty slice to placeholder %d in %q", n, query)
+			}
+			operand := s.operandStart(out, loc[0])
+			if operand < 0 {
+				return query, args, fmt.Errorf("cannot bind an empty slice to placeholder %d in %q", n, query)
+			}
+			predicate := "1=0"
+			if loc[2] >= 0 {
+				predicate = "1=1"
+			}
+			out = append(out[:operand], predicate...)
+			start += len(closing)
+		default:
+			for j := 0; j < rv.Len(); j++ {
+				if j > 0 {
+					out = append(out, ',')
+				}
+				out = append(out, '?')
+				bound = append(bound, rv.Index(j).Interface())
+			}
+		}
+	}
+	if n == 0 {
+		return query, args, nil
+	}
+	if n < len(args) {
+		return query, args, fmt.Errorf("%d arguments given for %d placeholders in %q", len(args), n, query)
+	}
+	out = append(out, query[start:]...)
+	return string(out), bound, nil
+}
+
+// operandStart returns the offset of the operand ending before offset end
+// of query, such as `t.id`, `"t"."id"`, `lower(name)` or `(a, b)`, or -1 if
+// there is none.
+func (s sqlScanner) operandStart(query []byte, end int) int {
+	i := end
+	for i > 0 && isSpace(query[i-1]) {
+		i--
+	}
+	operandEnd := i
+	for i > 0 {
+		switch c := query[i-1]; {
+		case c == ')':
+			j := s.openingParen(query, i-1)
+			if j < 0 {
+				return -1
+			}
+			i = j
+		case c == '\'' || c == '"' || c == '`':
+			j := bytes.LastIndexByte(query[:i-1], c)
+			if j < 0 {
+				return -1
+			}
+			i = j
+		case c == '_' || c == '.' || c == ':' || c == '$' || c >= '0' && c <= '9' ||
+			c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+			i--
+		default:
+			if i == operandEnd {
+				return -1
+			}
+			return i
+		}
+	}
+	if i == operandEnd {
+		return -1
+	}
+	return i
+}
+
+// openingParen returns the offset of the parenthesis of query closed by the
+// one at offset i, skipping quoted literals and identifiers, or -1 if there
+// is none.
+func (s sqlScanner) openingParen(query []byte, i int) int {
+	depth := 0
+	for ; i >= 0; i-- {
+		switch c := query[i]; c {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'', '"', '`':
+			i = bytes.LastIndexByte(query[:i], c)
+			if i < 0 {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// expandIn expands the placeholder of query passed to `IN (?)` into one
+// placeholder per remaining argument, for the variadic form of
+// `Where("id in (?)", 1, 2, 3)`. The query is returned unchanged unless it
+// has fewer placeholders than n arguments and exactly one of them is passed
+// to `IN (?)`.
+func (s sqlScanner) expandIn(query string, n int) string {
+	placeholders, in := 0, -1
+	for i := s.next(query, 0); i >= 0; i = s.next(query, i+1) {
+		placeholders++
+		if inArgRegex.MatchString(query[:i]) && closingRegex.MatchString(query[i+1:]) {
+			if in >= 0 {
+				return query
+			}
+			in = i
+		}
+	}
+	if in < 0 || placeholders >= n {
+		return query
+	}
+	marks := strings.TrimSuffix(strings.Repeat("?,", n-placeholders+1), ",")
+	return query[:in] + marks + query[in+1:]
+}
+
+// expandable returns the value of arg if it is a slice to expand into
+// multiple placeholders. Byte slices and values implementing
+// `driver.Valuer`, such as `pq.Array`, are bound as they are.
+func expandable(arg interface{}) (reflect.Value, bool) {
+	if arg == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+// rebind replaces the `?` placeholders of query with the ones of the given
+// sqlx bind type, such as `$1` for `sqlx.DOLLAR`.
+func (s sqlScanner) rebind(bindType int, query string) string {
+	var format string
+	switch bindType {
+	case sqlx.DOLLAR:
+		format = "$%d"
+	case sqlx.NAMED:
+		format = ":arg%d"
+	case sqlx.AT:
+		format = "@p%d"
+	default:
+		return query
+	}
+
+	var b strings.Builder
+	n, start := 0, 0
+	for {
+		i := s.next(query, start)
+		if i < 0 {
+			break
+		}
+		n++
+		b.WriteString(query[start:i])
+		fmt.Fprintf(&b, format, n)
+		start = i + 1
+	}
+	b.WriteString(query[start:])
+	return b.String()
+}
+
+// next returns the offset of the first placeholder of query at or after
+// start, or -1 if there is none.
+func (s sqlScanner) next(query string, start int) int {
+	return s.index(query, start, func(c byte) bool { return c == '?' })
+}
+
+// index returns the offset of the first byte of query at or after start
+// for which match returns true, skipping quoted literals, quoted
+// identifiers and comments, or -1 if there is none.
+func (s sqlScanner) index(query string, start int, match func(c byte) bool) int {
+	for i := start; i < len(query); {
+		switch c := query[i]; {
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				return -1
+			}
+			i += j + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return -1
+			}
+			i += j + 4
+		case match(c):
+			return i
+		case c == '\'' || c == '"' || c == '`':
+			i = s.skipQuoted(query, i)
+		case c == '$':
+			tag := dollarTag(query[i:])
+			if tag == "" {
+				i++
+				continue
+			}
+			j := strings.Index(query[i+len(tag):], tag)
+			if j < 0 {
+				return -1
+			}
+			i += j + 2*len(tag)
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// split returns the statements of query, separated by semicolons outside of
+// quoted literals, quoted identifiers and comments. The statements made of
+// comments only are dropped.
+func (s sqlScanner) split(query string) []string {
+	var stmts []string
+	for start := 0; start < len(query); {
+		end := s.index(query, start, func(c byte) bool { return c == ';' })
+		if end < 0 {
+			end = len(query)
+		}
+		stmt := query[start:end]
+		if s.index(stmt, 0, func(c byte) bool { return !isSpace(c) }) >= 0 {
+			stmts = append(stmts, strings.TrimSpace(stmt))
+		}
+		start = end + 1
+	}
+	return stmts
+}
+
+// skipQuoted returns the offset following the quoted literal or identifier
+// starting at offset i of query.
+func (s sqlScanner) skipQuoted(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if s.backslashEscapes && quote != '`' {
+				j++
+			}
+		case quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// dollarTag returns the tag opening a PostgreSQL dollar quoted string at the
+// start of s, such as `$$` or `$body$`, or an empty string.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		c := s[j]
+		switch {
+		case c == '$':
+			return s[:j+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && j > 1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sqlScanner_bind(t *testing.T) {
+	r := require.New(t)
+	s := sqlScanner{}
+
+	q, args, err := s.bind("SELECT * FROM users WHERE id IN (?) AND name = ?", []interface{}{[]int{1, 2, 3}, "Mark"})
+	r.NoError(err)
+	r.Equal("SELECT * FROM users WHERE id IN (?,?,?) AND name = ?", q)
+	r.Equal([]interface{}{1, 2, 3, "Mark"}, args)
+
+	// slices are expanded anywhere, bytes are not
+	q, args, err = s.bind("INSERT INTO t (a, b, c) VALUES (?, ?)", []interface{}{[]string{"x", "y"}, []byte("z")})
+	r.NoError(err)
+	r.Equal("INSERT INTO t (a, b, c) VALUES (?,?, ?)", q)
+	r.Equal([]interface{}{"x", "y", []byte("z")}, args)
+
+	// valuers are bound as they are
+	q, args, err = s.bind("SELECT * FROM t WHERE tags = ?", []interface{}{pq.StringArray{"a"}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE tags = ?", q)
+	r.Len(args, 1)
+
+	// placeholders in literals and comments are ignored
+	q, args, err = s.bind("SELECT '?', \"?\", `?` -- ?\n /* ? */ FROM t WHERE id IN (?) AND note = 'it''s ?'", []interface{}{[]int{1, 2}})
+	r.NoError(err)
+	r.Equal("SELECT '?', \"?\", `?` -- ?\n /* ? */ FROM t WHERE id IN (?,?) AND note = 'it''s ?'", q)
+	r.Equal([]interface{}{1, 2}, args)
+
+	q, _, err = s.bind("SELECT $$ ? $$, $body$ ? $body$ FROM t WHERE id = ?", []interface{}{1})
+	r.NoError(err)
+	r.Equal("SELECT $$ ? $$, $body$ ? $body$ FROM t WHERE id = ?", q)
+
+	// no arguments or no placeholders leave the query as it is
+	q, args, err = s.bind("SELECT * FROM t WHERE data ? 'key'", nil)
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE data ? 'key'", q)
+	r.Empty(args)
+
+	q, args, err = s.bind("SELECT * FROM t WHERE id = $1", []interface{}{1})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE id = $1", q)
+	r.Equal([]interface{}{1}, args)
+
+	_, _, err = s.bind("SELECT * FROM t WHERE id = ? AND name = ?", []interface{}{1})
+	r.Error(err)
+
+	_, _, err = s.bind("SELECT * FROM t WHERE id = ?", []interface{}{1, 2})
+	r.Error(err)
+}
+
+func Test_sqlScanner_bind_EmptySlice(t *testing.T) {
+	r := require.New(t)
+	s := sqlScanner{}
+
+	q, args, err := s.bind("SELECT * FROM t WHERE a = ? AND t.id IN (?) AND b = ?", []interface{}{1, []int{}, 2})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE a = ? AND 1=0 AND b = ?", q)
+	r.Equal([]interface{}{1, 2}, args)
+
+	q, _, err = s.bind(`SELECT * FROM t WHERE ("t"."id" not in ( ? ) OR b = ?)`, []interface{}{[]string{}, 2})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE (1=1 OR b = ?)", q)
+
+	q, _, err = s.bind("SELECT * FROM t WHERE lower(name) IN (?)", []interface{}{[]string{}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE 1=0", q)
+
+	// the operand is the whole expression before IN
+	q, _, err = s.bind("SELECT * FROM t WHERE a = ? AND (a, b) IN (?)", []interface{}{1, []int{}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE a = ? AND 1=0", q)
+
+	q, _, err = s.bind(`SELECT * FROM t WHERE (coalesce(t.a, ')', "b(") NOT IN (?))`, []interface{}{[]int{}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE (1=1)", q)
+
+	q, _, err = s.bind("SELECT * FROM t WHERE x = 1 OR name::text IN (?)", []interface{}{[]int{}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE x = 1 OR 1=0", q)
+
+	_, _, err = s.bind("INSERT INTO t (a) VALUES (?)", []interface{}{[]int{}})
+	r.Error(err)
+
+	_, _, err = s.bind("SELECT * FROM t WHERE (IN (?))", []interface{}{[]int{}})
+	r.Error(err)
+}
+
+func Test_sqlScanner_expandIn(t *testing.T) {
+	r := require.New(t)
+	s := sqlScanner{}
+
+	r.Equal("id in (?,?,?)", s.expandIn("id in (?)", 3))
+	r.Equal("a = ? AND id NOT IN ( ?,? )", s.expandIn("a = ? AND id NOT IN ( ? )", 3))
+	r.Equal("note = 'in (?)' AND id IN (?,?)", s.expandIn("note = 'in (?)' AND id IN (?)", 2))
+
+	// left as they are
+	r.Equal("id in (?)", s.expandIn("id in (?)", 1))
+	r.Equal("a in (?) OR b in (?)", s.expandIn("a in (?) OR b in (?)", 3))
+	r.Equal("id = ?", s.expandIn("id = ?", 2))
+}
+
+func Test_sqlScanner_bind_Dialects(t *testing.T) {
+	r := require.New(t)
+
+	s := sqlScanner{nativeArrays: true}
+	q, args, err := s.bind("SELECT * FROM t WHERE id = ANY(?) AND name IN (?)", []interface{}{[]int{1, 2}, []string{"a"}})
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE id = ANY(?) AND name IN (?)", q)
+	r.Equal([]interface{}{pq.Array([]int{1, 2}), "a"}, args)
+
+	s = sqlScanner{backslashEscapes: true}
+	q, args, err = s.bind(`SELECT * FROM t WHERE note = 'it\'s ?' AND id IN (?)`, []interface{}{[]int{1, 2}})
+	r.NoError(err)
+	r.Equal(`SELECT * FROM t WHERE note = 'it\'s ?' AND id IN (?,?)`, q)
+	r.Equal([]interface{}{1, 2}, args)
+}
+
+func Test_sqlScanner_split(t *testing.T) {
+	r := require.New(t)
+	s := sqlScanner{}
+
+	r.Equal([]string{
+		"CREATE INDEX CONCURRENTLY a_idx ON t (a)",
+		"INSERT INTO t (note) VALUES ('a;b')",
+		"-- first; of all\nCREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql",
+	}, s.split("CREATE INDEX CONCURRENTLY a_idx ON t (a);\nINSERT INTO t (note) VALUES ('a;b');\n-- first; of all\nCREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\n/* done; */\n-- end\n"))
+
+	r.Equal([]string{"SELECT 1"}, s.split("SELECT 1"))
+	r.Empty(s.split(" ;\n-- nothing\n"))
+}
+
+func Test_sqlScanner_rebind(t *testing.T) {
+	r := require.New(t)
+	s := sqlScanner{}
+
+	r.Equal("SELECT '?' FROM t WHERE a = $1 AND b IN ($2,$3)", s.rebind(sqlx.DOLLAR, "SELECT '?' FROM t WHERE a = ? AND b IN (?,?)"))
+	r.Equal("SELECT * FROM t WHERE a = @p1", s.rebind(sqlx.AT, "SELECT * FROM t WHERE a = ?"))
+	r.Equal("SELECT * FROM t WHERE a = ?", s.rebind(sqlx.QUESTION, "SELECT * FROM t WHERE a = ?"))
+}
+
+func Test_Bind_Integration(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		for _, title := range []string{"A", "B?", "C"} {
+			r.NoError(tx.Create(nil, &Song{Title: title}))
+		}
+
+		var songs []Song
+		r.NoError(tx.Where("title in (?)", []string{}).All(nil, &songs))
+		r.Empty(songs)
+
+		r.NoError(tx.Where("title NOT IN (?)", []string{}).All(nil, &songs))
+		r.Len(songs, 3)
+
+		r.NoError(tx.Where("title IN (?) AND title != 'A?'", []string{"A", "B?"}).Order("title").All(nil, &songs))
+		r.Len(songs, 2)
+		r.Equal("B?", songs[1].Title)
+
+		count, err := tx.RawQuery("SELECT * FROM songs WHERE title IN (?)", []string{"A", "C"}).Count(nil, &Song{})
+		r.NoError(err)
+		r.Equal(2, count)
+
+		r.Error(tx.RawQuery("SELECT * FROM songs WHERE title = ? AND id = ?", "A").All(nil, &songs))
+	})
+}
@@ -20,6 +20,10 @@ type AfterEagerFindable interface {
 }
 
 func (m *Model) afterFind(c *Connection, eager bool) error {
+	if !eager {
+		m.snapshot()
+	}
+
 	if eager {
 		if x, ok := m.Value.(AfterEagerFindable); ok {
 			if err := x.AfterEagerFind(c); err != nil {
@@ -20,6 +20,9 @@ import (
 // Connections contains all available connections
 var Connections = map[string]*Connection{}
 
+// errRollbackAsPlanned is used to roll back the savepoint of a nested Rollback.
+var errRollbackAsPlanned = errors.New("rollback as planned")
+
 // Connection represents all necessary details to talk with a datastore
 type Connection struct {
 	ID          string
@@ -29,6 +32,7 @@ type Connection struct {
 	TX          *Tx
 	eager       bool
 	eagerFields []string
+	replicas    *replicaSet
 }
 
 func (c *Connection) String() string {
@@ -140,64 +144,162 @@ func (c *Connection) Open(attributes ...attribute.KeyValue) error {
 			return fmt.Errorf("could not open database connection: %w", err)
 		}
 	}
+
+	if len(details.Replicas) > 0 {
+		rs, err := openReplicas(details, attributes...)
+		if err != nil {
+			c.Store.Close()
+			c.Store = nil
+			return err
+		}
+		c.replicas = rs
+	}
 	return nil
 }
 
 // Close destroys an active datasource connection
 func (c *Connection) Close() error {
+	var errs []error
+	if c.replicas != nil {
+		if err := c.replicas.close(); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't close replica connection: %w", err))
+		}
+		c.replicas = nil
+	}
 	if err := c.Store.Close(); err != nil {
-		return fmt.Errorf("couldn't close connection: %w", err)
+		errs = append(errs, fmt.Errorf("couldn't close connection: %w", err))
+	} else {
+		c.Store = nil
 	}
-	c.Store = nil
-	return nil
+	return errors.Join(errs...)
 }
 
 // Transaction will start a new transaction on the connection. If the inner function
 // returns an error then the transaction will be rolled back, otherwise the transaction
 // will automatically commit at the end.
+//
+// If the connection is already in a transaction, the inner function runs within
+// a savepoint instead: an error only rolls back the changes made by the inner
+// function, and the outer transaction carries on.
+//
+// If the "tx_retry_attempts" option of the connection is greater than 1, and
+// the transaction fails with an error the dialect considers retryable, such as
+// a serialization failure, the inner function is run again in a new
+// transaction. On CockroachDB, the client-side retry protocol based on the
+// "cockroach_restart" savepoint is used instead. The inner function must be
+// safe to run more than once.
 func (c *Connection) Transaction(requestID *uuid.UUID, fn func(tx *Connection) error) error {
-	return c.Dialect.Lock(func() (err error) {
-		var dberr error
+	requestID = c.requestID(requestID)
+	if c.TX != nil {
+		return c.savepointTransaction(requestID, fn)
+	}
+	run := func() error {
+		return c.Dialect.Lock(func() error {
+			return c.transaction(requestID, fn)
+		})
+	}
+	if _, ok := c.Dialect.(restartable); ok {
+		return run()
+	}
+	return c.retryTransaction(requestID, run)
+}
 
-		cn, err := c.NewTransaction()
-		if err != nil {
-			return err
-		}
-		txlog(logging.SQL, requestID, cn, "BEGIN Transaction ---")
-
-		defer func() {
-			if ex := recover(); ex != nil {
-				txlog(logging.SQL, requestID, cn, "ROLLBACK Transaction (inner function panic) ---")
-				dberr = cn.TX.Rollback()
-				if dberr != nil {
-					txlog(logging.Error, requestID, cn, "database error while inner panic rollback: %w", dberr)
-				}
-				panic(ex)
-			}
-		}()
+func (c *Connection) transaction(requestID *uuid.UUID, fn func(tx *Connection) error) (err error) {
+	var dberr error
 
-		err = fn(cn)
-		if err != nil {
-			txlog(logging.SQL, requestID, cn, "ROLLBACK Transaction ---")
+	cn, err := c.NewTransaction()
+	if err != nil {
+		return err
+	}
+	txlog(logging.SQL, requestID, cn, "BEGIN Transaction ---")
+
+	defer func() {
+		if ex := recover(); ex != nil {
+			txlog(logging.SQL, requestID, cn, "ROLLBACK Transaction (inner function panic) ---")
 			dberr = cn.TX.Rollback()
-		} else {
-			txlog(logging.SQL, requestID, cn, "END Transaction ---")
-			dberr = cn.TX.Commit()
+			if dberr != nil {
+				txlog(logging.Error, requestID, cn, "database error while inner panic rollback: %w", dberr)
+			}
+			cn.TX.runHooks(false)
+			panic(ex)
 		}
+	}()
+
+	if d, ok := c.Dialect.(restartable); ok && c.Dialect.Details().TxRetryAttempts() > 1 {
+		err = cn.restartTransaction(requestID, d.RestartSavepoint(), fn)
+	} else {
+		err = fn(cn)
+	}
+	if err != nil {
+		txlog(logging.SQL, requestID, cn, "ROLLBACK Transaction ---")
+		dberr = cn.TX.Rollback()
+	} else {
+		txlog(logging.SQL, requestID, cn, "END Transaction ---")
+		dberr = cn.TX.Commit()
+	}
+	cn.TX.runHooks(err == nil && dberr == nil)
+
+	if dberr != nil {
+		return fmt.Errorf("database error on committing or rolling back transaction: %w", dberr)
+	}
+
+	return err
+}
 
-		if dberr != nil {
-			return fmt.Errorf("database error on committing or rolling back transaction: %w", dberr)
+// savepointTransaction runs fn within a savepoint of the current transaction,
+// which is released if fn succeeds and rolled back otherwise.
+func (c *Connection) savepointTransaction(requestID *uuid.UUID, fn func(tx *Connection) error) (err error) {
+	sp := c.TX.nextSavepoint()
+	if _, err := genericExec(c, requestID, "", "SAVEPOINT "+sp); err != nil {
+		return fmt.Errorf("could not create savepoint: %w", err)
+	}
+	c.TX.pushHooks()
+
+	defer func() {
+		if ex := recover(); ex != nil {
+			txlog(logging.SQL, requestID, c, "ROLLBACK TO SAVEPOINT (inner function panic) ---")
+			if _, dberr := genericExec(c, requestID, "", "ROLLBACK TO SAVEPOINT "+sp); dberr != nil {
+				txlog(logging.Error, requestID, c, "database error while inner panic rollback: %w", dberr)
+			}
+			c.TX.popHooks(false)
+			panic(ex)
 		}
+	}()
 
-		return err
-	})
+	var dberr error
+	err = fn(c)
+	if err != nil {
+		_, dberr = genericExec(c, requestID, "", "ROLLBACK TO SAVEPOINT "+sp)
+	} else {
+		_, dberr = genericExec(c, requestID, "", "RELEASE SAVEPOINT "+sp)
+	}
+	c.TX.popHooks(err == nil && dberr == nil)
 
+	if dberr != nil {
+		return fmt.Errorf("database error on releasing or rolling back savepoint: %w", dberr)
+	}
+
+	return err
 }
 
 // Rollback will open a new transaction and automatically rollback that transaction
 // when the inner function returns, regardless. This can be useful for tests, etc...
+//
+// If the connection is already in a transaction, only the changes made by the
+// inner function are rolled back, using a savepoint.
 func (c *Connection) Rollback(requestID *uuid.UUID, fn func(tx *Connection)) error {
 	// TODO: the name of the method could be changed to express it better.
+	requestID = c.requestID(requestID)
+	if c.TX != nil {
+		err := c.savepointTransaction(requestID, func(tx *Connection) error {
+			fn(tx)
+			return errRollbackAsPlanned
+		})
+		if errors.Is(err, errRollbackAsPlanned) {
+			return nil
+		}
+		return err
+	}
 	cn, err := c.NewTransaction()
 	if err != nil {
 		return err
@@ -205,7 +307,9 @@ func (c *Connection) Rollback(requestID *uuid.UUID, fn func(tx *Connection)) err
 	txlog(logging.SQL, requestID, cn, "BEGIN Transaction for Rollback ---")
 	fn(cn)
 	txlog(logging.SQL, requestID, cn, "ROLLBACK Transaction as planned ---")
-	return cn.TX.Rollback()
+	err = cn.TX.Rollback()
+	cn.TX.runHooks(false)
+	return err
 }
 
 // NewTransaction starts a new transaction on the connection
@@ -255,9 +359,12 @@ func (c *Connection) copy() *Connection {
 	// related PRs: #72/#73, #79/#80, and #497
 
 	cn := &Connection{
-		Store:   c.Store,
-		Dialect: c.Dialect,
-		TX:      c.TX,
+		Store:       c.Store,
+		Dialect:     c.Dialect,
+		TX:          c.TX,
+		eager:       c.eager,
+		eagerFields: c.eagerFields,
+		replicas:    c.replicas,
 	}
 	cn.setID(c.ID) // ID of the source as a seed
 
@@ -92,7 +92,7 @@ func openPotentiallyInstrumentedConnection(c dialect, dsn string, attributes ...
 		return nil, err
 	}
 
-	con, err := otelsql.Open(driverName, dsn)
+	con, err := otelsql.Open(driverName, dsn, otelsql.WithAttributesGetter(requestIDAttributes))
 	if err != nil {
 		return nil, fmt.Errorf("could not open database connection: %w", err)
 	}
@@ -113,7 +113,7 @@ func openPotentiallyInstrumentedConnectionWithOtel(c dialect, dsn string, attrib
 		return nil, err
 	}
 
-	con, err := otelsql.Open(driverName, dsn)
+	con, err := otelsql.Open(driverName, dsn, otelsql.WithAttributesGetter(requestIDAttributes))
 	if err != nil {
 		return nil, fmt.Errorf("could not open database connection: %w", err)
 	}
@@ -38,7 +38,7 @@ func Test_Connection_Open_Close_Reopen(t *testing.T) {
 
 	for i := 0; i < 2; i++ {
 		r.NoError(c.Open())
-		r.NoError(c.Transaction(func(c *Connection) error { return nil }))
+		r.NoError(c.Transaction(nil, func(c *Connection) error { return nil }))
 		r.NoError(c.Close())
 	}
 }
@@ -124,14 +124,14 @@ func Test_Connection_Transaction(t *testing.T) {
 	r.NoError(c.Open())
 
 	t.Run("Success", func(t *testing.T) {
-		err = c.Transaction(func(c *Connection) error {
+		err = c.Transaction(nil, func(c *Connection) error {
 			return nil
 		})
 		r.NoError(err)
 	})
 
 	t.Run("Failed", func(t *testing.T) {
-		err = c.Transaction(func(c *Connection) error {
+		err = c.Transaction(nil, func(c *Connection) error {
 			return fmt.Errorf("failed")
 		})
 		r.ErrorContains(err, "failed")
@@ -139,7 +139,7 @@ func Test_Connection_Transaction(t *testing.T) {
 
 	t.Run("Panic", func(t *testing.T) {
 		r.PanicsWithValue("inner function panic", func() {
-			c.Transaction(func(c *Connection) error {
+			c.Transaction(nil, func(c *Connection) error {
 				panic("inner function panic")
 			})
 		})
@@ -0,0 +1,184 @@
+package pop
+
+import (
+	"context"
+
+	"github.com/gobuffalo/validate/v3"
+)
+
+// The functions in this file are variants of the Connection and Query API
+// which do not take a request ID. The request ID, if any, is read from the
+// given context (see `WithRequestID`), which is also used for the queries.
+
+// TransactionContext is like Transaction but takes the request ID from ctx.
+func (c *Connection) TransactionContext(ctx context.Context, fn func(tx *Connection) error) error {
+	return c.WithContext(ctx).Transaction(nil, fn)
+}
+
+// RollbackContext is like Rollback but takes the request ID from ctx.
+func (c *Connection) RollbackContext(ctx context.Context, fn func(tx *Connection)) error {
+	return c.WithContext(ctx).Rollback(nil, fn)
+}
+
+// ReloadContext is like Reload but takes the request ID from ctx.
+func (c *Connection) ReloadContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).Reload(nil, model)
+}
+
+// ValidateAndSaveContext is like ValidateAndSave but takes the request ID from ctx.
+func (c *Connection) ValidateAndSaveContext(ctx context.Context, model interface{}, excludeColumns ...string) (*validate.Errors, error) {
+	return c.WithContext(ctx).ValidateAndSave(nil, model, excludeColumns...)
+}
+
+// SaveContext is like Save but takes the request ID from ctx.
+func (c *Connection) SaveContext(ctx context.Context, model interface{}, excludeColumns ...string) error {
+	return c.WithContext(ctx).Save(nil, model, excludeColumns...)
+}
+
+// ValidateAndCreateContext is like ValidateAndCreate but takes the request ID from ctx.
+func (c *Connection) ValidateAndCreateContext(ctx context.Context, model interface{}, excludeColumns ...string) (*validate.Errors, error) {
+	return c.WithContext(ctx).ValidateAndCreate(nil, model, excludeColumns...)
+}
+
+// CreateContext is like Create but takes the request ID from ctx.
+func (c *Connection) CreateContext(ctx context.Context, model interface{}, excludeColumns ...string) error {
+	return c.WithContext(ctx).Create(nil, model, excludeColumns...)
+}
+
+// ValidateAndUpdateContext is like ValidateAndUpdate but takes the request ID from ctx.
+func (c *Connection) ValidateAndUpdateContext(ctx context.Context, model interface{}, excludeColumns ...string) (*validate.Errors, error) {
+	return c.WithContext(ctx).ValidateAndUpdate(nil, model, excludeColumns...)
+}
+
+// UpdateContext is like Update but takes the request ID from ctx.
+func (c *Connection) UpdateContext(ctx context.Context, model interface{}, excludeColumns ...string) error {
+	return c.WithContext(ctx).Update(nil, model, excludeColumns...)
+}
+
+// UpdateColumnsContext is like UpdateColumns but takes the request ID from ctx.
+func (c *Connection) UpdateColumnsContext(ctx context.Context, model interface{}, columnNames ...string) error {
+	return c.WithContext(ctx).UpdateColumns(nil, model, columnNames...)
+}
+
+// UpdateChangedContext is like UpdateChanged but takes the request ID from ctx.
+func (c *Connection) UpdateChangedContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).UpdateChanged(nil, model)
+}
+
+// CreateManyContext is like CreateMany but takes the request ID from ctx.
+func (c *Connection) CreateManyContext(ctx context.Context, models interface{}, excludeColumns ...string) error {
+	return c.WithContext(ctx).CreateMany(nil, models, excludeColumns...)
+}
+
+// UpsertContext is like Upsert but takes the request ID from ctx.
+func (c *Connection) UpsertContext(ctx context.Context, model interface{}, conflictColumns []string, updateColumns ...string) error {
+	return c.WithContext(ctx).Upsert(nil, model, conflictColumns, updateColumns...)
+}
+
+// DestroyContext is like Destroy but takes the request ID from ctx.
+func (c *Connection) DestroyContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).Destroy(nil, model)
+}
+
+// HardDestroyContext is like HardDestroy but takes the request ID from ctx.
+func (c *Connection) HardDestroyContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).HardDestroy(nil, model)
+}
+
+// RestoreContext is like Restore but takes the request ID from ctx.
+func (c *Connection) RestoreContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).Restore(nil, model)
+}
+
+// FindContext is like Find but takes the request ID from ctx.
+func (c *Connection) FindContext(ctx context.Context, model interface{}, id interface{}) error {
+	return c.WithContext(ctx).Find(nil, model, id)
+}
+
+// FirstContext is like First but takes the request ID from ctx.
+func (c *Connection) FirstContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).First(nil, model)
+}
+
+// LastContext is like Last but takes the request ID from ctx.
+func (c *Connection) LastContext(ctx context.Context, model interface{}) error {
+	return c.WithContext(ctx).Last(nil, model)
+}
+
+// AllContext is like All but takes the request ID from ctx.
+func (c *Connection) AllContext(ctx context.Context, models interface{}) error {
+	return c.WithContext(ctx).All(nil, models)
+}
+
+// CountContext is like Count but takes the request ID from ctx.
+func (c *Connection) CountContext(ctx context.Context, model interface{}) (int, error) {
+	return c.WithContext(ctx).Count(nil, model)
+}
+
+// withContext makes the query run with the given context.
+func (q *Query) withContext(ctx context.Context) *Query {
+	q.Connection = q.Connection.WithContext(ctx)
+	return q
+}
+
+// ExecContext is like Exec but takes the request ID from ctx.
+func (q *Query) ExecContext(ctx context.Context) error {
+	return q.withContext(ctx).Exec(nil)
+}
+
+// ExecWithCountContext is like ExecWithCount but takes the request ID from ctx.
+func (q *Query) ExecWithCountContext(ctx context.Context) (int, error) {
+	return q.withContext(ctx).ExecWithCount(nil)
+}
+
+// UpdateQueryContext is like UpdateQuery but takes the request ID from ctx.
+func (q *Query) UpdateQueryContext(ctx context.Context, model interface{}, columnNames ...string) (int64, error) {
+	return q.withContext(ctx).UpdateQuery(nil, model, columnNames...)
+}
+
+// DeleteContext is like Delete but takes the request ID from ctx.
+func (q *Query) DeleteContext(ctx context.Context, model interface{}) error {
+	return q.withContext(ctx).Delete(nil, model)
+}
+
+// FindContext is like Find but takes the request ID from ctx.
+func (q *Query) FindContext(ctx context.Context, model interface{}, id interface{}) error {
+	return q.withContext(ctx).Find(nil, model, id)
+}
+
+// FirstContext is like First but takes the request ID from ctx.
+func (q *Query) FirstContext(ctx context.Context, model interface{}) error {
+	return q.withContext(ctx).First(nil, model)
+}
+
+// LastContext is like Last but takes the request ID from ctx.
+func (q *Query) LastContext(ctx context.Context, model interface{}) error {
+	return q.withContext(ctx).Last(nil, model)
+}
+
+// AllContext is like All but takes the request ID from ctx.
+func (q *Query) AllContext(ctx context.Context, models interface{}) error {
+	return q.withContext(ctx).All(nil, models)
+}
+
+// EachContext is like Each but takes the request ID from ctx, and stops when
+// ctx is done.
+func (q *Query) EachContext(ctx context.Context, model interface{}, fn func(model interface{}) error) error {
+	return q.withContext(ctx).Each(nil, model, fn)
+}
+
+// FindInBatchesContext is like FindInBatches but takes the request ID from
+// ctx, and stops when ctx is done.
+func (q *Query) FindInBatchesContext(ctx context.Context, size int, models interface{}, fn func(models interface{}) error) error {
+	return q.withContext(ctx).FindInBatches(nil, size, models, fn)
+}
+
+// CountContext is like Count but takes the request ID from ctx.
+func (q *Query) CountContext(ctx context.Context, model interface{}) (int, error) {
+	return q.withContext(ctx).Count(nil, model)
+}
+
+// CountByFieldContext is like CountByField but takes the request ID from ctx.
+func (q *Query) CountByFieldContext(ctx context.Context, model interface{}, field string) (int, error) {
+	return q.withContext(ctx).CountByField(nil, model, field)
+}
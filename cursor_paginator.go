@@ -0,0 +1,416 @@
+package pop
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Accefy/pop/internal/defaults"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// PaginatorCursorKey is the query parameter holding the cursor of the current
+// page, for cursor based pagination
+var PaginatorCursorKey = "cursor"
+
+var _ paginable = CursorPaginator{}
+
+// CursorPaginator is a type used to represent the cursor (or keyset) based
+// pagination of records from the database. Instead of skipping a number of
+// records, a page starts right after (or before) the record a cursor points
+// to, which keeps pages fast and stable on large tables.
+type CursorPaginator struct {
+	// Columns the records are ordered by. A column prefixed with "-" is
+	// sorted in descending order. The last column must be unique, such as
+	// the primary key. The NULL values of the columns mapped to nullable
+	// fields are sorted after the others in ascending order.
+	Columns []string `json:"-"`
+	// Number of results you want per page
+	PerPage int `json:"per_page"`
+	// Cursor of the current page, as returned by Next or Prev
+	Cursor string `json:"cursor,omitempty"`
+	// Cursor of the next page, empty on the last page
+	Next string `json:"next,omitempty"`
+	// Cursor of the previous page, empty on the first page
+	Prev string `json:"prev,omitempty"`
+	// Total records returns, will be <= PerPage
+	CurrentEntriesSize int `json:"current_entries_size"`
+	// CountTotal enables the count of TotalEntriesSize, which needs an
+	// additional query.
+	CountTotal bool `json:"-"`
+	// Total potential records matching the query, if CountTotal is set
+	TotalEntriesSize int `json:"total_entries_size,omitempty"`
+}
+
+// Paginate implements the paginable interface.
+func (p CursorPaginator) Paginate() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func (p CursorPaginator) String() string {
+	return p.Paginate()
+}
+
+// NewCursorPaginator returns a new `CursorPaginator` value, starting at the
+// given cursor, or at the first page if cursor is empty.
+func NewCursorPaginator(cursor string, perPage int, columns ...string) *CursorPaginator {
+	if perPage < 1 {
+		perPage = PaginatorPerPageDefault
+	}
+	if len(columns) == 0 {
+		columns = []string{"id"}
+	}
+	return &CursorPaginator{Cursor: cursor, PerPage: perPage, Columns: columns}
+}
+
+// NewCursorPaginatorFromParams takes an interface of type `PaginationParams`,
+// and returns a new `CursorPaginator` based on the params of
+// `PaginatorCursorKey` and `PaginatorPerPageKey`.
+func NewCursorPaginatorFromParams(params PaginationParams, columns ...string) *CursorPaginator {
+	perPage := defaults.String(params.Get(PaginatorPerPageKey), strconv.Itoa(PaginatorPerPageDefault))
+	pp, err := strconv.Atoi(perPage)
+	if err != nil {
+		pp = PaginatorPerPageDefault
+	}
+	return NewCursorPaginator(params.Get(PaginatorCursorKey), pp, columns...)
+}
+
+// PaginateByCursor paginates records returned from the database with cursors,
+// ordering them by the given columns ("id" by default). Pass the Next or Prev
+// cursor of a page to get the page after or before it.
+//
+//	q := c.PaginateByCursor("", 15, "-created_at", "id")
+//	q.All(&[]User{})
+//	q.CursorPaginator.Next
+func (c *Connection) PaginateByCursor(cursor string, perPage int, columns ...string) *Query {
+	return Q(c).PaginateByCursor(cursor, perPage, columns...)
+}
+
+// PaginateByCursor paginates records returned from the database with cursors,
+// ordering them by the given columns ("id" by default). Any other order
+// clause of the query is ignored.
+//
+//	q = q.PaginateByCursor(cursor, 15, "-created_at", "id")
+//	q.All(&[]User{})
+//	q.CursorPaginator.Next
+func (q *Query) PaginateByCursor(cursor string, perPage int, columns ...string) *Query {
+	q.CursorPaginator = NewCursorPaginator(cursor, perPage, columns...)
+	return q
+}
+
+// PaginateByCursorFromParams paginates records returned from the database
+// with cursors, reading the cursor and the page size from params.
+//
+//	q = q.PaginateByCursorFromParams(req.URL.Query(), "-created_at", "id")
+//	q.All(&[]User{})
+//	q.CursorPaginator
+func (q *Query) PaginateByCursorFromParams(params PaginationParams, columns ...string) *Query {
+	q.CursorPaginator = NewCursorPaginatorFromParams(params, columns...)
+	return q
+}
+
+var fieldMapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+type cursorColumn struct {
+	name string
+	desc bool
+	// nullable is set for the columns mapped to a nullable field, whose NULL
+	// values are sorted after the others in ascending order.
+	nullable bool
+}
+
+// field returns the name of the column without table prefix.
+func (cc cursorColumn) field() string {
+	return cc.name[strings.LastIndex(cc.name, ".")+1:]
+}
+
+// quoted returns the quoted name of the column, quoting the table prefix
+// separately.
+func (cc cursorColumn) quoted(quoter quotable) string {
+	return quoteColumn(quoter, cc.name)
+}
+
+// cursor is the decoded form of a page cursor.
+type cursor struct {
+	// Prev is set for cursors pointing to the page before a record.
+	Prev   bool              `json:"p,omitempty"`
+	Values []json.RawMessage `json:"v"`
+}
+
+func (p *CursorPaginator) columns() ([]cursorColumn, error) {
+	if len(p.Columns) == 0 {
+		return nil, errors.New("cursor pagination needs at least one column")
+	}
+	cols := make([]cursorColumn, len(p.Columns))
+	for i, c := range p.Columns {
+		cols[i] = cursorColumn{name: strings.TrimPrefix(c, "-"), desc: strings.HasPrefix(c, "-")}
+	}
+	return cols, nil
+}
+
+// encodeCursor returns the cursor pointing to the given record.
+func encodeCursor(record reflect.Value, cols []cursorColumn, prev bool) (string, error) {
+	record = reflect.Indirect(record)
+	c := cursor{Prev: prev}
+	for _, col := range cols {
+		f := fieldMapper.FieldByName(record, col.field())
+		if !f.IsValid() {
+			return "", fmt.Errorf("could not find cursor column %s", col.name)
+		}
+		b, err := json.Marshal(f.Interface())
+		if err != nil {
+			return "", fmt.Errorf("could not encode cursor column %s: %w", col.name, err)
+		}
+		c.Values = append(c.Values, b)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the values of the record the cursor s points to,
+// converted to the types of the fields of t.
+func decodeCursor(s string, t reflect.Type, cols []cursorColumn) ([]interface{}, bool, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, false, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(c.Values) != len(cols) {
+		return nil, false, errors.New("invalid cursor: columns do not match")
+	}
+
+	tm := fieldMapper.TypeMap(t)
+	values := make([]interface{}, len(cols))
+	for i, col := range cols {
+		fi := tm.GetByPath(col.field())
+		if fi == nil {
+			return nil, false, fmt.Errorf("could not find cursor column %s", col.name)
+		}
+		v := reflect.New(fi.Field.Type)
+		if err := json.Unmarshal(c.Values[i], v.Interface()); err != nil {
+			return nil, false, fmt.Errorf("invalid cursor: %w", err)
+		}
+		values[i] = v.Elem().Interface()
+	}
+	return values, c.Prev, nil
+}
+
+// cursorWhere returns the condition selecting the records after the given
+// values in the order of cols, or before them if prev is set. A row
+// comparison is used when all the columns are sorted in the same direction
+// and none of them is nullable.
+func cursorWhere(cols []cursorColumn, values []interface{}, prev bool, quoter quotable) (string, []interface{}) {
+	op := func(col cursorColumn) string {
+		if col.desc != prev {
+			return "<"
+		}
+		return ">"
+	}
+
+	uniform := true
+	for _, col := range cols {
+		uniform = uniform && col.desc == cols[0].desc && !col.nullable
+	}
+	if uniform {
+		names := make([]string, len(cols))
+		marks := make([]string, len(cols))
+		for i, col := range cols {
+			names[i] = col.quoted(quoter)
+			marks[i] = "?"
+		}
+		if len(cols) == 1 {
+			return fmt.Sprintf("%s %s ?", names[0], op(cols[0])), values
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), op(cols[0]), strings.Join(marks, ", ")), values
+	}
+
+	var ors []string
+	var args []interface{}
+	for i, col := range cols {
+		cmp, cmpArgs, ok := cursorCompare(col, values[i], op(col), quoter)
+		if !ok {
+			continue
+		}
+		var ands []string
+		for j := 0; j < i; j++ {
+			eq, eqArgs := cursorEqual(cols[j], values[j], quoter)
+			ands = append(ands, eq)
+			args = append(args, eqArgs...)
+		}
+		ands = append(ands, cmp)
+		args = append(args, cmpArgs...)
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	if len(ors) == 0 {
+		return "1 = 0", nil
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// cursorEqual returns the condition selecting the records whose col is
+// equal to value.
+func cursorEqual(col cursorColumn, value interface{}, quoter quotable) (string, []interface{}) {
+	if col.nullable && isNullValue(value) {
+		return col.quoted(quoter) + " IS NULL", nil
+	}
+	return col.quoted(quoter) + " = ?", []interface{}{value}
+}
+
+// cursorCompare returns the condition selecting the records whose col is
+// after value for op, NULL being greater than any value. ok is false when
+// no record can match.
+func cursorCompare(col cursorColumn, value interface{}, op string, quoter quotable) (string, []interface{}, bool) {
+	name := col.quoted(quoter)
+	switch {
+	case !col.nullable:
+		return fmt.Sprintf("%s %s ?", name, op), []interface{}{value}, true
+	case isNullValue(value) && op == ">":
+		return "", nil, false
+	case isNullValue(value):
+		return name + " IS NOT NULL", nil, true
+	case op == ">":
+		return fmt.Sprintf("(%s > ? OR %s IS NULL)", name, name), []interface{}{value}, true
+	default:
+		return fmt.Sprintf("%s < ?", name), []interface{}{value}, true
+	}
+}
+
+// nullableType returns true for the field types which can hold NULL, such
+// as pointers and the sql.Null and nulls types.
+func nullableType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return true
+	case reflect.Struct:
+		f, ok := t.FieldByName("Valid")
+		return ok && f.Type.Kind() == reflect.Bool
+	}
+	return false
+}
+
+// isNullValue returns true if v is stored as NULL in the database.
+func isNullValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		return err == nil && dv == nil
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// allWithCursor retrieves the page of records described by the cursor
+// paginator of the query, and sets the cursors of the pages around it.
+func (q *Query) allWithCursor(requestID *uuid.UUID, models interface{}) error {
+	p := q.CursorPaginator
+	v := reflect.ValueOf(models)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("models must be a pointer to a slice; got %T", models)
+	}
+	cols, err := p.columns()
+	if err != nil {
+		return err
+	}
+	elemType := v.Elem().Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	tm := fieldMapper.TypeMap(elemType)
+	for i := range cols {
+		if fi := tm.GetByPath(cols[i].field()); fi != nil {
+			cols[i].nullable = nullableType(fi.Field.Type)
+		}
+	}
+
+	var values []interface{}
+	prev := false
+	if p.Cursor != "" {
+		values, prev, err = decodeCursor(p.Cursor, elemType, cols)
+		if err != nil {
+			return err
+		}
+	}
+
+	cq := Q(q.Connection)
+	q.Clone(cq)
+	cq.eager = q.eager
+	cq.eagerFields = q.eagerFields
+	cq.eagerMode = q.eagerMode
+	cq.CursorPaginator = nil
+	cq.Paginator = nil
+	cq.orderClauses = clauses{}
+	if values != nil {
+		where, args := cursorWhere(cols, values, prev, q.Connection.Dialect)
+		cq.Where(where, args...)
+	}
+	for _, col := range cols {
+		dir := "ASC"
+		if col.desc != prev {
+			dir = "DESC"
+		}
+		if col.nullable {
+			// sort NULL values the same way on every database
+			cq.Order(col.quoted(q.Connection.Dialect) + " IS NULL " + dir)
+		}
+		cq.Order(col.quoted(q.Connection.Dialect) + " " + dir)
+	}
+	cq.Limit(p.PerPage + 1)
+
+	err = cq.All(requestID, models)
+	q.disableEager()
+	if err != nil {
+		return err
+	}
+
+	s := v.Elem()
+	more := s.Len() > p.PerPage
+	if more {
+		s.Set(s.Slice(0, p.PerPage))
+	}
+	if prev {
+		for i, j := 0, s.Len()-1; i < j; i, j = i+1, j-1 {
+			a, b := s.Index(i).Interface(), s.Index(j).Interface()
+			s.Index(i).Set(reflect.ValueOf(b))
+			s.Index(j).Set(reflect.ValueOf(a))
+		}
+	}
+
+	p.CurrentEntriesSize = s.Len()
+	p.Next, p.Prev = "", ""
+	if s.Len() > 0 {
+		if (prev && more) || (!prev && p.Cursor != "") {
+			if p.Prev, err = encodeCursor(s.Index(0), cols, true); err != nil {
+				return err
+			}
+		}
+		if prev || more {
+			if p.Next, err = encodeCursor(s.Index(s.Len()-1), cols, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.CountTotal {
+		p.TotalEntriesSize, err = q.Count(requestID, models)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package pop
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewCursorPaginatorFromParams(t *testing.T) {
+	r := require.New(t)
+
+	p := NewCursorPaginatorFromParams(url.Values{})
+	r.Equal("", p.Cursor)
+	r.Equal(PaginatorPerPageDefault, p.PerPage)
+	r.Equal([]string{"id"}, p.Columns)
+
+	p = NewCursorPaginatorFromParams(url.Values{"cursor": {"abc"}, "per_page": {"5"}}, "-created_at", "id")
+	r.Equal("abc", p.Cursor)
+	r.Equal(5, p.PerPage)
+	r.Equal([]string{"-created_at", "id"}, p.Columns)
+}
+
+func Test_Cursor_EncodeDecode(t *testing.T) {
+	r := require.New(t)
+
+	p := NewCursorPaginator("", 10, "-name", "id")
+	cols, err := p.columns()
+	r.NoError(err)
+
+	u := User{ID: 42, Name: nulls.NewString("Mark")}
+	s, err := encodeCursor(reflect.ValueOf(&u), cols, true)
+	r.NoError(err)
+
+	values, prev, err := decodeCursor(s, reflect.TypeOf(u), cols)
+	r.NoError(err)
+	r.True(prev)
+	r.Equal([]interface{}{nulls.NewString("Mark"), 42}, values)
+
+	_, _, err = decodeCursor("not a cursor", reflect.TypeOf(u), cols)
+	r.Error(err)
+
+	_, _, err = decodeCursor(s, reflect.TypeOf(u), cols[:1])
+	r.Error(err)
+}
+
+func Test_cursorWhere(t *testing.T) {
+	r := require.New(t)
+	d := &postgresql{}
+
+	cols := []cursorColumn{{name: "users.id"}}
+	where, args := cursorWhere(cols, []interface{}{1}, false, d)
+	r.Equal(`"users"."id" > ?`, where)
+	r.Equal([]interface{}{1}, args)
+
+	cols = []cursorColumn{{name: "name", desc: true}, {name: "id", desc: true}}
+	where, args = cursorWhere(cols, []interface{}{"Mark", 1}, false, d)
+	r.Equal(`("name", "id") < (?, ?)`, where)
+	r.Equal([]interface{}{"Mark", 1}, args)
+
+	where, _ = cursorWhere(cols, []interface{}{"Mark", 1}, true, d)
+	r.Equal(`("name", "id") > (?, ?)`, where)
+
+	cols = []cursorColumn{{name: "name", desc: true}, {name: "id"}}
+	where, args = cursorWhere(cols, []interface{}{"Mark", 1}, false, &mysql{})
+	r.Equal("((`name` < ?) OR (`name` = ? AND `id` > ?))", where)
+	r.Equal([]interface{}{"Mark", "Mark", 1}, args)
+
+	// NULL is greater than any value of a nullable column
+	cols = []cursorColumn{{name: "name", nullable: true}, {name: "id"}}
+	where, args = cursorWhere(cols, []interface{}{nulls.NewString("Mark"), 1}, false, d)
+	r.Equal(`((("name" > ? OR "name" IS NULL)) OR ("name" = ? AND "id" > ?))`, where)
+	r.Equal([]interface{}{nulls.NewString("Mark"), nulls.NewString("Mark"), 1}, args)
+
+	where, args = cursorWhere(cols, []interface{}{nulls.String{}, 1}, false, d)
+	r.Equal(`(("name" IS NULL AND "id" > ?))`, where)
+	r.Equal([]interface{}{1}, args)
+
+	where, args = cursorWhere(cols, []interface{}{nulls.String{}, 1}, true, d)
+	r.Equal(`(("name" IS NOT NULL) OR ("name" IS NULL AND "id" < ?))`, where)
+	r.Equal([]interface{}{1}, args)
+
+	where, args = cursorWhere(cols[:1], []interface{}{nulls.String{}}, false, d)
+	r.Equal("1 = 0", where)
+	r.Empty(args)
+}
+
+func Test_nullableType(t *testing.T) {
+	r := require.New(t)
+
+	r.True(nullableType(reflect.TypeOf(nulls.String{})))
+	r.True(nullableType(reflect.TypeOf(sql.NullInt64{})))
+	r.True(nullableType(reflect.TypeOf(new(int))))
+	r.False(nullableType(reflect.TypeOf(0)))
+	r.False(nullableType(reflect.TypeOf(time.Time{})))
+	r.False(nullableType(reflect.TypeOf(uuid.UUID{})))
+}
+
+func Test_PaginateByCursor(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		for _, name := range []string{"Mark", "Joe", "Jane", "Mark", "Ann"} {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString(name)}))
+		}
+
+		names := func(u Users) []string {
+			var n []string
+			for _, user := range u {
+				n = append(n, user.Name.String)
+			}
+			return n
+		}
+
+		u := Users{}
+		q := tx.PaginateByCursor("", 2, "-name", "id")
+		q.CursorPaginator.CountTotal = true
+		r.NoError(q.All(nil, &u))
+		r.Equal([]string{"Mark", "Mark"}, names(u))
+		p := q.CursorPaginator
+		r.Equal(2, p.CurrentEntriesSize)
+		r.Equal(5, p.TotalEntriesSize)
+		r.Empty(p.Prev)
+		r.NotEmpty(p.Next)
+
+		u = Users{}
+		q = tx.PaginateByCursor(p.Next, 2, "-name", "id")
+		r.NoError(q.All(nil, &u))
+		r.Equal([]string{"Joe", "Jane"}, names(u))
+		p = q.CursorPaginator
+		r.NotEmpty(p.Prev)
+		r.NotEmpty(p.Next)
+		prev := p.Prev
+
+		u = Users{}
+		q = tx.PaginateByCursor(p.Next, 2, "-name", "id")
+		r.NoError(q.All(nil, &u))
+		r.Equal([]string{"Ann"}, names(u))
+		r.Empty(q.CursorPaginator.Next)
+		r.NotEmpty(q.CursorPaginator.Prev)
+
+		u = Users{}
+		q = tx.PaginateByCursor(prev, 2, "-name", "id")
+		r.NoError(q.All(nil, &u))
+		r.Equal([]string{"Mark", "Mark"}, names(u))
+		r.Empty(q.CursorPaginator.Prev)
+		r.NotEmpty(q.CursorPaginator.Next)
+
+		// the records where a cursor column is NULL are not skipped
+		for i, userID := range []nulls.Int{nulls.NewInt(2), {}, nulls.NewInt(1), {}, nulls.NewInt(2)} {
+			r.NoError(tx.Create(nil, &Book{Title: fmt.Sprintf("book %d", i), Isbn: "isbn", UserID: userID}))
+		}
+		titles := func(b []Book) []string {
+			var t []string
+			for _, book := range b {
+				t = append(t, book.Title)
+			}
+			return t
+		}
+		var all []string
+		var q2 *Query
+		cursor := ""
+		for {
+			b := []Book{}
+			q2 = tx.PaginateByCursor(cursor, 2, "user_id", "id")
+			r.NoError(q2.All(nil, &b))
+			all = append(all, titles(b)...)
+			if cursor = q2.CursorPaginator.Next; cursor == "" {
+				break
+			}
+		}
+		r.Equal([]string{"book 2", "book 0", "book 4", "book 1", "book 3"}, all)
+
+		var back []string
+		cursor = q2.CursorPaginator.Prev
+		for cursor != "" {
+			b := []Book{}
+			q2 = tx.PaginateByCursor(cursor, 2, "user_id", "id")
+			r.NoError(q2.All(nil, &b))
+			back = append(titles(b), back...)
+			cursor = q2.CursorPaginator.Prev
+		}
+		r.Equal(all[:len(all)-1], back)
+
+		var desc []string
+		cursor = ""
+		for {
+			b := []Book{}
+			q2 = tx.PaginateByCursor(cursor, 2, "-user_id", "id")
+			r.NoError(q2.All(nil, &b))
+			desc = append(desc, titles(b)...)
+			if cursor = q2.CursorPaginator.Next; cursor == "" {
+				break
+			}
+		}
+		r.Equal([]string{"book 1", "book 3", "book 0", "book 4", "book 2"}, desc)
+
+		u = Users{}
+		q = tx.Where("name = ?", "Mark").PaginateByCursor("", 1)
+		r.NoError(q.All(nil, &u))
+		r.Len(u, 1)
+		r.NotEmpty(q.CursorPaginator.Next)
+	})
+}
@@ -16,6 +16,8 @@ type crudable interface {
 	UpdateQuery(*Connection, *uuid.UUID, *Model, columns.Columns, Query) (int64, error)
 	Destroy(*Connection, *uuid.UUID, *Model) error
 	Delete(*Connection, *uuid.UUID, *Model, Query) error
+	Upsert(*Connection, *uuid.UUID, *Model, columns.Columns, []string, []string) error
+	CreateMany(*Connection, *uuid.UUID, []*Model, columns.Columns) error
 }
 
 type fizzable interface {
@@ -47,3 +49,19 @@ type dialect interface {
 type afterOpenable interface {
 	AfterOpen(*Connection) error
 }
+
+type explainable interface {
+	Explain(c *Connection, stmt string, args ...interface{}) (string, error)
+}
+
+type lockable interface {
+	LockClause(l rowLock) string
+}
+
+type retryable interface {
+	IsRetryable(err error) bool
+}
+
+type restartable interface {
+	RestartSavepoint() string
+}
@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Accefy/pop/columns"
 	"github.com/Accefy/pop/internal/defaults"
@@ -83,8 +84,11 @@ func (p *cockroach) Create(c *Connection, requestID *uuid.UUID, model *Model, co
 		} else {
 			query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s", p.Quote(model.TableName()), model.IDField())
 		}
-		txlog(logging.SQL, requestID, c, query, model.Value)
-		rows, err := c.Store.NamedQueryContext(model.ctx, query, model.Value)
+		var rows *sqlx.Rows
+		err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+			rows, err = c.Store.NamedQueryContext(model.ctx, query, model.Value)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("named insert: %w", err)
 		}
@@ -120,8 +124,11 @@ func (p *cockroach) Create(c *Connection, requestID *uuid.UUID, model *Model, co
 			w.Add(model.IDField())
 			query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s", p.Quote(model.TableName()), w.QuotedString(p), w.SymbolizedString(), model.IDField())
 		}
-		txlog(logging.SQL, requestID, c, query, model.Value)
-		rows, err := c.Store.NamedQueryContext(model.ctx, query, model.Value)
+		var rows *sqlx.Rows
+		err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+			rows, err = c.Store.NamedQueryContext(model.ctx, query, model.Value)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("named insert: %w", err)
 		}
@@ -155,7 +162,7 @@ func (p *cockroach) UpdateQuery(c *Connection, requestID *uuid.UUID, model *Mode
 
 func (p *cockroach) Destroy(c *Connection, requestID *uuid.UUID, model *Model) error {
 	stmt := p.TranslateSQL(fmt.Sprintf("DELETE FROM %s AS %s WHERE %s", p.Quote(model.TableName()), model.Alias(), model.WhereID()))
-	_, err := genericExec(c, requestID, stmt, model.ID())
+	_, err := genericExec(c, requestID, model.TableName(), stmt, model.ID())
 	return err
 }
 
@@ -163,6 +170,14 @@ func (p *cockroach) Delete(c *Connection, requestID *uuid.UUID, model *Model, qu
 	return genericDelete(c, requestID, model, query)
 }
 
+func (p *cockroach) CreateMany(c *Connection, requestID *uuid.UUID, models []*Model, cols columns.Columns) error {
+	return genericCreateMany(c, requestID, models, cols, p, sqlx.DOLLAR, true)
+}
+
+func (p *cockroach) Upsert(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, updateColumns []string) error {
+	return genericUpsert(c, requestID, model, cols, conflictColumns, onConflictDoUpdate(conflictColumns, updateColumns, p), p)
+}
+
 func (p *cockroach) SelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
 	return genericSelectOne(c, requestID, model, query)
 }
@@ -171,6 +186,41 @@ func (p *cockroach) SelectMany(c *Connection, requestID *uuid.UUID, models *Mode
 	return genericSelectMany(c, requestID, models, query)
 }
 
+// Explain returns the query plan of the given statement.
+func (p *cockroach) Explain(c *Connection, stmt string, args ...interface{}) (string, error) {
+	return genericExplain(c, "EXPLAIN", stmt, args...)
+}
+
+// IsRetryable returns true if the transaction failed with a retry error and
+// can be run again.
+func (p *cockroach) IsRetryable(err error) bool {
+	return isSerializationFailure(err)
+}
+
+// TranslateError turns the constraint violations reported by CockroachDB
+// into the typed errors of pop.
+func (p *cockroach) TranslateError(table string, err error) error {
+	return translatePostgreSQLError(table, err)
+}
+
+// RestartSavepoint returns the name of the savepoint used by the client-side
+// transaction retry protocol of CockroachDB.
+func (p *cockroach) RestartSavepoint() string {
+	return "cockroach_restart"
+}
+
+// LockMigrations runs fn while holding a lease row in the migration lock
+// table, since CockroachDB does not implement advisory locks. The lock table
+// is named after the migration table, with a "_lock" suffix.
+func (p *cockroach) LockMigrations(c *Connection, timeout time.Duration, fn func(c *Connection) error) error {
+	return lockMigrationsWithLease(c, timeout, fn)
+}
+
+// LockClause returns the row locking clause of a SELECT statement.
+func (p *cockroach) LockClause(l rowLock) string {
+	return genericLockClause(l)
+}
+
 func (p *cockroach) CreateDB() error {
 	// createdb -h db -p 5432 -U cockroach enterprise_development
 	deets := p.ConnectionDetails
@@ -238,7 +288,7 @@ func (p *cockroach) TranslateSQL(sql string) string {
 	if csql, ok := p.translateCache[sql]; ok {
 		return csql
 	}
-	csql := sqlx.Rebind(sqlx.DOLLAR, sql)
+	csql := sqlScanner{}.rebind(sqlx.DOLLAR, sql)
 
 	p.translateCache[sql] = csql
 	return csql
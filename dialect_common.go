@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/Accefy/pop/columns"
@@ -53,8 +54,11 @@ func genericCreate(c *Connection, requestID *uuid.UUID, model *Model, cols colum
 		cols.Remove(model.IDField())
 		w := cols.Writeable()
 		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoter.Quote(model.TableName()), w.QuotedString(quoter), w.SymbolizedString())
-		txlog(logging.SQL, requestID, c, query, model.Value)
-		res, err := c.Store.NamedExecContext(model.ctx, query, model.Value)
+		var res sql.Result
+		err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+			res, err = c.Store.NamedExecContext(model.ctx, query, model.Value)
+			return err
+		})
 		if err != nil {
 			return err
 		}
@@ -81,8 +85,11 @@ func genericCreate(c *Connection, requestID *uuid.UUID, model *Model, cols colum
 		w := cols.Writeable()
 		w.Add(model.IDField())
 		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoter.Quote(model.TableName()), w.QuotedString(quoter), w.SymbolizedString())
-		txlog(logging.SQL, requestID, c, query, model.Value)
-		if _, err := c.Store.NamedExecContext(model.ctx, query, model.Value); err != nil {
+		err := logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+			_, err := c.Store.NamedExecContext(model.ctx, query, model.Value)
+			return err
+		})
+		if err != nil {
 			return fmt.Errorf("named insert: %w", err)
 		}
 		return nil
@@ -91,13 +98,41 @@ func genericCreate(c *Connection, requestID *uuid.UUID, model *Model, cols colum
 }
 
 func genericUpdate(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, quoter quotable) error {
+	vc := model.versionColumn()
+	if vc != "" {
+		return genericVersionedUpdate(c, requestID, model, cols, quoter, vc)
+	}
 	stmt := fmt.Sprintf("UPDATE %s AS %s SET %s WHERE %s", quoter.Quote(model.TableName()), model.Alias(), cols.Writeable().QuotedUpdateString(quoter), model.WhereNamedID())
-	txlog(logging.SQL, requestID, c, stmt, model.ID())
-	_, err := c.Store.NamedExecContext(model.ctx, stmt, model.Value)
+	return logSQL(requestID, c, model.TableName(), stmt, []interface{}{model.ID()}, func() error {
+		_, err := c.Store.NamedExecContext(model.ctx, stmt, model.Value)
+		return err
+	})
+}
+
+// genericVersionedUpdate updates a versioned model, only if its version
+// column still matches the database. The version is incremented on success.
+func genericVersionedUpdate(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, quoter quotable, column string) error {
+	version, err := model.versionField(column)
 	if err != nil {
 		return err
 	}
-	return nil
+	set, where := versionedUpdate(model, column, cols, quoter)
+	stmt := fmt.Sprintf("UPDATE %s AS %s SET %s WHERE %s", quoter.Quote(model.TableName()), model.Alias(), set, where)
+	return logSQL(requestID, c, model.TableName(), stmt, []interface{}{model.ID(), version.Interface()}, func() error {
+		res, err := c.Store.NamedExecContext(model.ctx, stmt, model.Value)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%s %v: %w", model.TableName(), model.ID(), ErrStaleObject)
+		}
+		version.SetInt(version.Int() + 1)
+		return nil
+	})
 }
 
 func genericUpdateQuery(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, quoter quotable, query Query, bindType int) (int64, error) {
@@ -108,12 +143,20 @@ func genericUpdateQuery(c *Connection, requestID *uuid.UUID, model *Model, cols
 		return 0, err
 	}
 
+	if query.err != nil {
+		return 0, query.err
+	}
 	sb := query.toSQLBuilder(model)
 	q = sb.buildWhereClauses(q)
 
-	q = sqlx.Rebind(bindType, q)
+	scanner := newSQLScanner(c.Dialect)
+	q, args, err := scanner.bind(q, append(updateArgs, sb.args...))
+	if err != nil {
+		return 0, err
+	}
+	q = scanner.rebind(bindType, q)
 
-	result, err := genericExec(c, requestID, q, append(updateArgs, sb.args...)...)
+	result, err := genericExec(c, requestID, model.TableName(), q, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -128,7 +171,7 @@ func genericUpdateQuery(c *Connection, requestID *uuid.UUID, model *Model, cols
 
 func genericDestroy(c *Connection, requestID *uuid.UUID, model *Model, quoter quotable) error {
 	stmt := fmt.Sprintf("DELETE FROM %s AS %s WHERE %s", quoter.Quote(model.TableName()), model.Alias(), model.WhereID())
-	_, err := genericExec(c, requestID, stmt, model.ID())
+	_, err := genericExec(c, requestID, model.TableName(), stmt, model.ID())
 	if err != nil {
 		return err
 	}
@@ -136,37 +179,319 @@ func genericDestroy(c *Connection, requestID *uuid.UUID, model *Model, quoter qu
 }
 
 func genericDelete(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
-	sqlQuery, args := query.ToSQL(model)
-	_, err := genericExec(c, requestID, sqlQuery, args...)
+	sqlQuery, args, err := query.toSQL(model)
+	if err != nil {
+		return err
+	}
+	_, err = genericExec(c, requestID, model.TableName(), sqlQuery, args...)
 	return err
 }
 
-func genericExec(c *Connection, requestID *uuid.UUID, stmt string, args ...interface{}) (sql.Result, error) {
-	txlog(logging.SQL, requestID, c, stmt, args...)
-	res, err := c.Store.ExecContext(c.Context(), stmt, args...)
+func genericExec(c *Connection, requestID *uuid.UUID, table string, stmt string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := logSQL(requestID, c, table, stmt, args, func() error {
+		var err error
+		res, err = c.Store.ExecContext(c.Context(), stmt, args...)
+		return err
+	})
 	return res, err
 }
 
-func genericSelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
-	sqlQuery, args := query.ToSQL(model)
-	txlog(logging.SQL, requestID, query.Connection, sqlQuery, args...)
-	err := c.Store.GetContext(model.ctx, model.Value, sqlQuery, args...)
+// maxBindParams is the lowest limit of bind parameters in a single statement
+// among the supported databases (SQLite).
+const maxBindParams = 32766
+
+// genericCreateMany inserts the models with multi-row INSERT statements, in
+// batches of the size set by the "bulk_insert_batch_size" option. Generated
+// integer IDs are read back with a RETURNING clause if returning is set, and
+// derived from the last insert ID of each batch otherwise.
+func genericCreateMany(c *Connection, requestID *uuid.UUID, models []*Model, cols columns.Columns, quoter quotable, bindType int, returning bool) error {
+	if len(models) == 0 {
+		return nil
+	}
+	model := models[0]
+	keyType, err := model.PrimaryKeyType()
 	if err != nil {
 		return err
 	}
+	var w *columns.WriteableColumns
+	switch keyType {
+	case "int", "int64":
+		cols.Remove(model.IDField())
+		w = cols.Writeable()
+	case "UUID", "string":
+		for _, m := range models {
+			if keyType == "UUID" {
+				if m.ID() == emptyUUID {
+					u, err := uuid.NewV4()
+					if err != nil {
+						return err
+					}
+					m.setID(u)
+				}
+			} else if m.ID() == "" {
+				return fmt.Errorf("missing ID value")
+			}
+		}
+		w = cols.Writeable()
+		w.Add(model.IDField())
+	default:
+		return fmt.Errorf("can not use %s as a primary key type!", keyType)
+	}
+
+	if len(w.Cols) == 0 {
+		return fmt.Errorf("bulk insert: no columns to insert")
+	}
+
+	isInt := keyType == "int" || keyType == "int64"
+	batchSize := c.Dialect.Details().BulkInsertBatchSize()
+	if limit := maxBindParams / len(w.Cols); batchSize > limit {
+		batchSize = limit
+	}
+
+	row := fmt.Sprintf("(%s)", w.SymbolizedString())
+	for start := 0; start < len(models); start += batchSize {
+		batch := models[start:min(start+batchSize, len(models))]
+
+		values := make([]string, len(batch))
+		var args []interface{}
+		for i, m := range batch {
+			v, a, err := sqlx.Named(row, m.Value)
+			if err != nil {
+				return fmt.Errorf("bulk insert: %w", err)
+			}
+			values[i] = v
+			args = append(args, a...)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoter.Quote(model.TableName()), w.QuotedString(quoter), strings.Join(values, ", "))
+		if isInt && returning {
+			query += " RETURNING " + quoter.Quote(model.IDField())
+		}
+		query = sqlx.Rebind(bindType, query)
+
+		switch {
+		case isInt && returning:
+			err = createManyReturning(c, requestID, batch, query, args)
+		case isInt:
+			err = createManyLastInsertID(c, requestID, batch, query, args)
+		default:
+			err = logSQL(requestID, c, model.TableName(), query, args, func() error {
+				_, err := c.Store.ExecContext(model.ctx, query, args...)
+				return err
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("bulk insert: %w", err)
+		}
+	}
 	return nil
 }
 
-func genericSelectMany(c *Connection, requestID *uuid.UUID, models *Model, query Query) error {
-	sqlQuery, args := query.ToSQL(models)
-	txlog(logging.SQL, requestID, query.Connection, sqlQuery, args...)
-	err := c.Store.SelectContext(models.ctx, models.Value, sqlQuery, args...)
+// createManyReturning runs the given INSERT ... RETURNING statement, and
+// assigns the returned IDs to the models. The rows of a multi-row
+// INSERT ... VALUES are inserted in order, so their generated IDs ascend in
+// the order of the models, while the order of the rows returned by
+// RETURNING is not guaranteed: the IDs are sorted before being assigned.
+func createManyReturning(c *Connection, requestID *uuid.UUID, models []*Model, query string, args []interface{}) error {
+	model := models[0]
+	var rows *sqlx.Rows
+	err := logSQL(requestID, c, model.TableName(), query, args, func() error {
+		var err error
+		rows, err = c.Store.QueryxContext(model.ctx, query, args...)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, len(models))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("next: %w", err)
+	}
+	if len(ids) != len(models) {
+		return fmt.Errorf("%d IDs returned for %d rows inserted", len(ids), len(models))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for i, m := range models {
+		m.setID(ids[i])
+	}
+	return rows.Close()
+}
+
+// createManyLastInsertID runs the given INSERT statement, and assigns IDs to
+// the models from the last insert ID, which is the ID of the first row, and
+// the auto_increment_increment of the session. This relies on MySQL
+// allocating the IDs of all the rows of a multi-row INSERT ... VALUES at
+// once, which it does in every innodb_autoinc_lock_mode.
+func createManyLastInsertID(c *Connection, requestID *uuid.UUID, models []*Model, query string, args []interface{}) error {
+	model := models[0]
+	var res sql.Result
+	err := logSQL(requestID, c, model.TableName(), query, args, func() error {
+		var err error
+		res, err = c.Store.ExecContext(model.ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	step := int64(1)
+	if len(models) > 1 {
+		stmt := "SELECT @@auto_increment_increment"
+		err = logSQL(requestID, c, "", stmt, nil, func() error {
+			return c.Store.GetContext(model.ctx, &step, stmt)
+		})
+		if err != nil {
+			return fmt.Errorf("could not read auto_increment_increment: %w", err)
+		}
+	}
+	for i, m := range models {
+		m.setID(id + int64(i)*step)
+	}
 	return nil
 }
 
+// genericUpsert inserts the model, followed by the given conflict clause,
+// and reloads the ID and timestamps of the model from the resulting row.
+func genericUpsert(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, onConflict string, quoter quotable) error {
+	keyType, err := model.PrimaryKeyType()
+	if err != nil {
+		return err
+	}
+	var w *columns.WriteableColumns
+	switch keyType {
+	case "int", "int64":
+		cols.Remove(model.IDField())
+		w = cols.Writeable()
+	case "UUID", "string":
+		if keyType == "UUID" {
+			if model.ID() == emptyUUID {
+				u, err := uuid.NewV4()
+				if err != nil {
+					return err
+				}
+				model.setID(u)
+			}
+		} else if model.ID() == "" {
+			return fmt.Errorf("missing ID value")
+		}
+		w = cols.Writeable()
+		w.Add(model.IDField())
+	default:
+		return fmt.Errorf("can not use %s as a primary key type!", keyType)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) %s", quoter.Quote(model.TableName()), w.QuotedString(quoter), w.SymbolizedString(), onConflict)
+	err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+		_, err := c.Store.NamedExecContext(model.ctx, query, model.Value)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("named upsert: %w", err)
+	}
+	return genericUpsertReload(c, requestID, model, cols, conflictColumns, quoter)
+}
+
+// genericUpsertReload reads the ID and timestamps of the row matching the
+// conflict columns of the model back into the model.
+func genericUpsertReload(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, quoter quotable) error {
+	fields := []struct{ field, column string }{
+		{"ID", model.IDField()},
+		{"CreatedAt", "created_at"},
+		{"UpdatedAt", "updated_at"},
+	}
+
+	var selects []string
+	var dests []interface{}
+	for _, f := range fields {
+		fbn, err := model.fieldByName(f.field)
+		if err != nil {
+			continue
+		}
+		if _, ok := cols.Cols[f.column]; !ok && f.field != "ID" {
+			continue
+		}
+		selects = append(selects, quoter.Quote(f.column))
+		dests = append(dests, fbn.Addr().Interface())
+	}
+
+	wheres := make([]string, len(conflictColumns))
+	for i, col := range conflictColumns {
+		wheres[i] = fmt.Sprintf("%s = :%s", quoter.Quote(col), col)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(selects, ", "), quoter.Quote(model.TableName()), strings.Join(wheres, " AND "))
+
+	var rows *sqlx.Rows
+	err := logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+		var err error
+		rows, err = c.Store.NamedQueryContext(model.ctx, query, model.Value)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("upsert reload: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("upsert reload: next: %w", err)
+		}
+		return fmt.Errorf("upsert reload: %w", sql.ErrNoRows)
+	}
+	if err := rows.Scan(dests...); err != nil {
+		return fmt.Errorf("upsert reload: scan: %w", err)
+	}
+	return rows.Close()
+}
+
+// onConflictDoUpdate returns the ON CONFLICT clause of an upsert, as known by
+// PostgreSQL, CockroachDB and SQLite.
+func onConflictDoUpdate(conflictColumns []string, updateColumns []string, quoter quotable) string {
+	conflict := make([]string, len(conflictColumns))
+	for i, col := range conflictColumns {
+		conflict[i] = quoter.Quote(col)
+	}
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(conflict, ", "))
+	}
+	sets := make([]string, len(updateColumns))
+	for i, col := range updateColumns {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", quoter.Quote(col), quoter.Quote(col))
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(conflict, ", "), strings.Join(sets, ", "))
+}
+
+func genericSelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
+	sqlQuery, args, err := query.toSQL(model)
+	if err != nil {
+		return err
+	}
+	return logSQL(requestID, query.Connection, model.TableName(), sqlQuery, args, func() error {
+		return c.Store.GetContext(model.ctx, model.Value, sqlQuery, args...)
+	})
+}
+
+func genericSelectMany(c *Connection, requestID *uuid.UUID, models *Model, query Query) error {
+	sqlQuery, args, err := query.toSQL(models)
+	if err != nil {
+		return err
+	}
+	return logSQL(requestID, query.Connection, models.TableName(), sqlQuery, args, func() error {
+		return c.Store.SelectContext(models.ctx, models.Value, sqlQuery, args...)
+	})
+}
+
 func genericLoadSchema(d dialect, r io.Reader) error {
 	deets := d.Details()
 
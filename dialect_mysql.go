@@ -2,11 +2,15 @@ package pop
 
 import (
 	"bytes"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Accefy/pop/columns"
 	"github.com/Accefy/pop/internal/defaults"
@@ -107,7 +111,7 @@ func (m *mysql) UpdateQuery(c *Connection, requestID *uuid.UUID, model *Model, c
 
 func (m *mysql) Destroy(c *Connection, requestID *uuid.UUID, model *Model) error {
 	stmt := fmt.Sprintf("DELETE FROM %s  WHERE %s = ?", m.Quote(model.TableName()), model.IDField())
-	_, err := genericExec(c, requestID, stmt, model.ID())
+	_, err := genericExec(c, requestID, model.TableName(), stmt, model.ID())
 	if err != nil {
 		return fmt.Errorf("mysql destroy: %w", err)
 	}
@@ -117,16 +121,48 @@ func (m *mysql) Destroy(c *Connection, requestID *uuid.UUID, model *Model) error
 var asRegex = regexp.MustCompile(`\sAS\s\S+`) // exactly " AS non-spaces"
 
 func (m *mysql) Delete(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
-	sqlQuery, args := query.ToSQL(model)
+	sqlQuery, args, err := query.toSQL(model)
+	if err != nil {
+		return err
+	}
 	// * MySQL does not support table alias for DELETE syntax until 8.0.
 	// * Do not generate SQL manually if they may have `WHERE IN`.
 	// * Spaces are intentionally added to make it easy to see on the log.
 	sqlQuery = asRegex.ReplaceAllString(sqlQuery, "  ")
 
-	_, err := genericExec(c, requestID, sqlQuery, args...)
+	_, err = genericExec(c, requestID, model.TableName(), sqlQuery, args...)
 	return err
 }
 
+func (m *mysql) CreateMany(c *Connection, requestID *uuid.UUID, models []*Model, cols columns.Columns) error {
+	if err := genericCreateMany(c, requestID, models, cols, m, sqlx.QUESTION, false); err != nil {
+		return fmt.Errorf("mysql create many: %w", err)
+	}
+	return nil
+}
+
+func (m *mysql) Upsert(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, updateColumns []string) error {
+	if err := genericUpsert(c, requestID, model, cols, conflictColumns, m.onDuplicateKeyUpdate(model, updateColumns), m); err != nil {
+		return fmt.Errorf("mysql upsert: %w", err)
+	}
+	return nil
+}
+
+// onDuplicateKeyUpdate returns the ON DUPLICATE KEY UPDATE clause of an
+// upsert. Without columns to update, the ID is assigned to itself so the
+// existing row is left untouched.
+func (m *mysql) onDuplicateKeyUpdate(model *Model, updateColumns []string) string {
+	if len(updateColumns) == 0 {
+		id := m.Quote(model.IDField())
+		return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", id, id)
+	}
+	sets := make([]string, len(updateColumns))
+	for i, col := range updateColumns {
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", m.Quote(col), m.Quote(col))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
 func (m *mysql) SelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
 	if err := genericSelectOne(c, requestID, model, query); err != nil {
 		return fmt.Errorf("mysql select one: %w", err)
@@ -141,6 +177,102 @@ func (m *mysql) SelectMany(c *Connection, requestID *uuid.UUID, models *Model, q
 	return nil
 }
 
+// Explain returns the query plan of the given statement.
+func (m *mysql) Explain(c *Connection, stmt string, args ...interface{}) (string, error) {
+	return genericExplain(c, "EXPLAIN", stmt, args...)
+}
+
+// LockClause returns the row locking clause of a SELECT statement. MariaDB
+// does not know FOR SHARE, and uses LOCK IN SHARE MODE instead.
+func (m *mysql) LockClause(l rowLock) string {
+	if l.Strength == "SHARE" && m.Details().Dialect == nameMariaDB {
+		s := "LOCK IN SHARE MODE"
+		if l.Wait != "" {
+			s += " " + l.Wait
+		}
+		return s
+	}
+	return genericLockClause(l)
+}
+
+var (
+	mysqlDuplicateKeyRegex = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlForeignKeyRegex   = regexp.MustCompile("\\(`[^`]+`\\.`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\)")
+	mysqlCheckRegex        = regexp.MustCompile("(?:Check constraint '([^']+)'|CONSTRAINT `([^`]+)` failed)")
+	mysqlColumnRegex       = regexp.MustCompile(`(?:Column|Field) '([^']+)'`)
+)
+
+// LockMigrations runs fn while holding a named lock of the server, so
+// processes running migrations on the same database wait for each other.
+func (m *mysql) LockMigrations(c *Connection, timeout time.Duration, fn func(c *Connection) error) error {
+	conn, release, err := c.session(c.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// named locks are global to the server, and their names are limited to
+	// 64 characters
+	name := fmt.Sprintf("%s.%s", m.Details().Database, migrationLockName(c))
+	if len(name) > 64 {
+		name = fmt.Sprintf("%s:%016x", name[:47], uint64(migrationLockKey(c)))
+	}
+
+	var locked sql.NullInt64
+	err = c.sessionGet(conn, &locked, "SELECT GET_LOCK(?, ?)", name, int(math.Ceil(timeout.Seconds())))
+	if err != nil {
+		return fmt.Errorf("could not get the migration lock: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("%w: gave up after %s", ErrMigrationLocked, timeout)
+	}
+
+	err = fn(c)
+	var unlocked sql.NullInt64
+	if uerr := c.sessionGet(conn, &unlocked, "SELECT RELEASE_LOCK(?)", name); uerr != nil && err == nil {
+		err = fmt.Errorf("could not release the migration lock: %w", uerr)
+	}
+	return err
+}
+
+// TranslateError turns the constraint violations reported by MySQL and
+// MariaDB into the typed errors of pop.
+func (m *mysql) TranslateError(table string, err error) error {
+	var myErr *_mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Number {
+	case 1062, 1586: // ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		v := &UniqueViolation{Table: table, Err: err}
+		if sm := mysqlDuplicateKeyRegex.FindStringSubmatch(myErr.Message); sm != nil {
+			// MySQL 8 reports the key as table.key
+			v.Constraint = sm[1][strings.LastIndex(sm[1], ".")+1:]
+		}
+		return v
+	case 1451, 1452: // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		v := &ForeignKeyViolation{Table: table, Err: err}
+		if sm := mysqlForeignKeyRegex.FindStringSubmatch(myErr.Message); sm != nil {
+			v.Table, v.Constraint, v.Columns = sm[1], sm[2], splitColumns(sm[3])
+		}
+		return v
+	case 3819, 4025: // ER_CHECK_CONSTRAINT_VIOLATED, MariaDB ER_CONSTRAINT_FAILED
+		v := &CheckViolation{Table: table, Err: err}
+		if sm := mysqlCheckRegex.FindStringSubmatch(myErr.Message); sm != nil {
+			v.Constraint = sm[1] + sm[2]
+		}
+		return v
+	case 1048, 1364: // ER_BAD_NULL_ERROR, ER_NO_DEFAULT_FOR_FIELD
+		v := &NotNullViolation{Table: table, Err: err}
+		if sm := mysqlColumnRegex.FindStringSubmatch(myErr.Message); sm != nil {
+			v.Column = sm[1]
+		}
+		return v
+	}
+	return err
+}
+
 // CreateDB creates a new database, from the given connection credentials
 func (m *mysql) CreateDB() error {
 	deets := m.ConnectionDetails
@@ -2,11 +2,14 @@ package pop
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os/exec"
+	"regexp"
 	"sync"
+	"time"
 
 	"github.com/Accefy/pop/columns"
 	"github.com/Accefy/pop/internal/defaults"
@@ -17,6 +20,7 @@ import (
 	"github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4/stdlib" // Load pgx driver
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const namePostgreSQL = "postgres"
@@ -67,8 +71,11 @@ func (p *postgresql) Create(c *Connection, requestID *uuid.UUID, model *Model, c
 		} else {
 			query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s", p.Quote(model.TableName()), model.IDField())
 		}
-		txlog(logging.SQL, requestID, c, query, model.Value)
-		rows, err := c.Store.NamedQueryContext(model.ctx, query, model.Value)
+		var rows *sqlx.Rows
+		err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+			rows, err = c.Store.NamedQueryContext(model.ctx, query, model.Value)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("named insert: %w", err)
 		}
@@ -102,7 +109,7 @@ func (p *postgresql) UpdateQuery(c *Connection, requestID *uuid.UUID, model *Mod
 
 func (p *postgresql) Destroy(c *Connection, requestID *uuid.UUID, model *Model) error {
 	stmt := p.TranslateSQL(fmt.Sprintf("DELETE FROM %s AS %s WHERE %s", p.Quote(model.TableName()), model.Alias(), model.WhereID()))
-	_, err := genericExec(c, requestID, stmt, model.ID())
+	_, err := genericExec(c, requestID, model.TableName(), stmt, model.ID())
 	if err != nil {
 		return err
 	}
@@ -113,6 +120,14 @@ func (p *postgresql) Delete(c *Connection, requestID *uuid.UUID, model *Model, q
 	return genericDelete(c, requestID, model, query)
 }
 
+func (p *postgresql) CreateMany(c *Connection, requestID *uuid.UUID, models []*Model, cols columns.Columns) error {
+	return genericCreateMany(c, requestID, models, cols, p, sqlx.DOLLAR, true)
+}
+
+func (p *postgresql) Upsert(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, updateColumns []string) error {
+	return genericUpsert(c, requestID, model, cols, conflictColumns, onConflictDoUpdate(conflictColumns, updateColumns, p), p)
+}
+
 func (p *postgresql) SelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
 	return genericSelectOne(c, requestID, model, query)
 }
@@ -121,6 +136,34 @@ func (p *postgresql) SelectMany(c *Connection, requestID *uuid.UUID, models *Mod
 	return genericSelectMany(c, requestID, models, query)
 }
 
+// Explain returns the query plan of the given statement.
+func (p *postgresql) Explain(c *Connection, stmt string, args ...interface{}) (string, error) {
+	return genericExplain(c, "EXPLAIN", stmt, args...)
+}
+
+// IsRetryable returns true if the transaction failed with a serialization
+// failure and can be run again.
+func (p *postgresql) IsRetryable(err error) bool {
+	return isSerializationFailure(err)
+}
+
+// TranslateError turns the constraint violations reported by PostgreSQL into
+// the typed errors of pop.
+func (p *postgresql) TranslateError(table string, err error) error {
+	return translatePostgreSQLError(table, err)
+}
+
+// LockMigrations runs fn while holding a session level advisory lock, so
+// processes running migrations on the same database wait for each other.
+func (p *postgresql) LockMigrations(c *Connection, timeout time.Duration, fn func(c *Connection) error) error {
+	return lockMigrationsWithAdvisoryLock(c, timeout, fn)
+}
+
+// LockClause returns the row locking clause of a SELECT statement.
+func (p *postgresql) LockClause(l rowLock) string {
+	return genericLockClause(l)
+}
+
 func (p *postgresql) CreateDB() error {
 	// createdb -h db -p 5432 -U postgres enterprise_development
 	deets := p.ConnectionDetails
@@ -192,7 +235,7 @@ func (p *postgresql) TranslateSQL(sql string) string {
 	if csql, ok := p.translateCache[sql]; ok {
 		return csql
 	}
-	csql := sqlx.Rebind(sqlx.DOLLAR, sql)
+	csql := sqlScanner{}.rebind(sqlx.DOLLAR, sql)
 
 	p.translateCache[sql] = csql
 	return csql
@@ -226,6 +269,98 @@ func newPostgreSQL(deets *ConnectionDetails) (dialect, error) {
 	return cd, nil
 }
 
+// isSerializationFailure returns true if err is a PostgreSQL (or compatible)
+// error with SQLSTATE 40001.
+func isSerializationFailure(err error) bool {
+	return postgreSQLErrorCode(err) == "40001"
+}
+
+// isUniqueViolation returns true if err is a PostgreSQL (or compatible)
+// error with SQLSTATE 23505.
+func isUniqueViolation(err error) bool {
+	return postgreSQLErrorCode(err) == "23505"
+}
+
+// postgreSQLErrorCode returns the SQLSTATE of a pgx or lib/pq error, or an
+// empty string if err is neither.
+func postgreSQLErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.Code
+	case errors.As(err, &pqErr):
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+var postgreSQLKeyRegex = regexp.MustCompile(`^Key \((.+?)\)=`)
+
+// lockMigrationsWithAdvisoryLock runs fn while holding the advisory lock of
+// the migrations of c. The lock is polled with pg_try_advisory_lock so that
+// the timeout does not depend on the settings of the server.
+func lockMigrationsWithAdvisoryLock(c *Connection, timeout time.Duration, fn func(c *Connection) error) error {
+	conn, release, err := c.session(c.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	key := migrationLockKey(c)
+	err = waitForMigrationLock(timeout, func() (bool, error) {
+		var locked bool
+		err := c.sessionGet(conn, &locked, "SELECT pg_try_advisory_lock($1)", key)
+		return locked, err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = fn(c)
+	var unlocked bool
+	if uerr := c.sessionGet(conn, &unlocked, "SELECT pg_advisory_unlock($1)", key); uerr != nil && err == nil {
+		err = fmt.Errorf("could not release the migration lock: %w", uerr)
+	}
+	return err
+}
+
+// translatePostgreSQLError turns the constraint violations reported by
+// PostgreSQL (or compatible) drivers into the typed errors of pop.
+func translatePostgreSQLError(table string, err error) error {
+	var code, constraint, tbl, column, detail string
+	var pgErr *pgconn.PgError
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pgErr):
+		code, constraint, tbl, column, detail = pgErr.Code, pgErr.ConstraintName, pgErr.TableName, pgErr.ColumnName, pgErr.Detail
+	case errors.As(err, &pqErr):
+		code, constraint, tbl, column, detail = string(pqErr.Code), pqErr.Constraint, pqErr.Table, pqErr.Column, pqErr.Detail
+	default:
+		return err
+	}
+	if tbl == "" {
+		tbl = table
+	}
+
+	var cols []string
+	if m := postgreSQLKeyRegex.FindStringSubmatch(detail); m != nil {
+		cols = splitColumns(m[1])
+	}
+
+	switch code {
+	case "23505":
+		return &UniqueViolation{Table: tbl, Constraint: constraint, Columns: cols, Err: err}
+	case "23503":
+		return &ForeignKeyViolation{Table: tbl, Constraint: constraint, Columns: cols, Err: err}
+	case "23514":
+		return &CheckViolation{Table: tbl, Constraint: constraint, Err: err}
+	case "23502":
+		return &NotNullViolation{Table: tbl, Column: column, Err: err}
+	}
+	return err
+}
+
 // urlParserPostgreSQL parses the options the same way jackc/pgconn does:
 // https://pkg.go.dev/github.com/jackc/pgconn?tab=doc#ParseConfig
 // After parsed, they are set to ConnectionDetails instance
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -88,8 +89,11 @@ func (m *sqlite) Create(c *Connection, requestID *uuid.UUID, model *Model, cols
 			} else {
 				query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", m.Quote(model.TableName()))
 			}
-			txlog(logging.SQL, requestID, c, query, model.Value)
-			res, err := c.Store.NamedExecContext(model.ctx, query, model.Value)
+			var res sql.Result
+			err = logSQL(requestID, c, model.TableName(), query, []interface{}{model.Value}, func() error {
+				res, err = c.Store.NamedExecContext(model.ctx, query, model.Value)
+				return err
+			})
 			if err != nil {
 				return err
 			}
@@ -145,6 +149,24 @@ func (m *sqlite) Delete(c *Connection, requestID *uuid.UUID, model *Model, query
 	return genericDelete(c, requestID, model, query)
 }
 
+func (m *sqlite) CreateMany(c *Connection, requestID *uuid.UUID, models []*Model, cols columns.Columns) error {
+	return m.locker(m.smGil, func() error {
+		if err := genericCreateMany(c, requestID, models, cols, m, sqlx.QUESTION, true); err != nil {
+			return fmt.Errorf("sqlite create many: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *sqlite) Upsert(c *Connection, requestID *uuid.UUID, model *Model, cols columns.Columns, conflictColumns []string, updateColumns []string) error {
+	return m.locker(m.smGil, func() error {
+		if err := genericUpsert(c, requestID, model, cols, conflictColumns, onConflictDoUpdate(conflictColumns, updateColumns, m), m); err != nil {
+			return fmt.Errorf("sqlite upsert: %w", err)
+		}
+		return nil
+	})
+}
+
 func (m *sqlite) SelectOne(c *Connection, requestID *uuid.UUID, model *Model, query Query) error {
 	return m.locker(m.smGil, func() error {
 		if err := genericSelectOne(c, requestID, model, query); err != nil {
@@ -163,6 +185,50 @@ func (m *sqlite) SelectMany(c *Connection, requestID *uuid.UUID, models *Model,
 	})
 }
 
+// Explain returns the query plan of the given statement.
+func (m *sqlite) Explain(c *Connection, stmt string, args ...interface{}) (string, error) {
+	return genericExplain(c, "EXPLAIN QUERY PLAN", stmt, args...)
+}
+
+var sqliteConstraintRegex = regexp.MustCompile(`(UNIQUE|FOREIGN KEY|CHECK|NOT NULL) constraint failed(?:: (.+))?`)
+
+// TranslateError turns the constraint violations reported by SQLite into the
+// typed errors of pop. The driver is not always compiled in, so the error
+// message is matched instead of the error type.
+func (m *sqlite) TranslateError(table string, err error) error {
+	sm := sqliteConstraintRegex.FindStringSubmatch(err.Error())
+	if sm == nil {
+		return err
+	}
+
+	// columns are reported as table.column
+	var cols []string
+	for _, col := range strings.Split(sm[2], ",") {
+		col = strings.TrimSpace(col)
+		if i := strings.Index(col, "."); i >= 0 {
+			table, col = col[:i], col[i+1:]
+		}
+		if col != "" {
+			cols = append(cols, col)
+		}
+	}
+
+	switch sm[1] {
+	case "UNIQUE":
+		return &UniqueViolation{Table: table, Columns: cols, Err: err}
+	case "FOREIGN KEY":
+		return &ForeignKeyViolation{Table: table, Err: err}
+	case "CHECK":
+		return &CheckViolation{Table: table, Constraint: sm[2], Err: err}
+	default:
+		v := &NotNullViolation{Table: table, Err: err}
+		if len(cols) > 0 {
+			v.Column = cols[0]
+		}
+		return v
+	}
+}
+
 func (m *sqlite) Lock(fn func() error) error {
 	return m.locker(m.gil, fn)
 }
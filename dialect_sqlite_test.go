@@ -196,3 +196,63 @@ func TestSqlite_NewDriver(t *testing.T) {
 	_, err := newSQLiteDriver()
 	require.NoError(t, err)
 }
+
+func Test_ConnectionDetails_Replicas_SQLite(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	c, err := NewConnection(&ConnectionDetails{
+		Dialect:  "sqlite3",
+		Database: filepath.Join(dir, "primary.sqlite"),
+		Replicas: []string{"sqlite3://" + filepath.Join(dir, "replica.sqlite") + "?_fk=true"},
+	})
+	r.NoError(err)
+	r.NoError(c.Open())
+	defer c.Close()
+	r.Len(c.replicas.replicas, 1)
+
+	const stmt = "CREATE TABLE markers (id integer PRIMARY KEY, name text)"
+	r.NoError(c.RawQuery(stmt).Exec(nil))
+	r.NoError(c.replicas.replicas[0].conn.RawQuery(stmt).Exec(nil))
+	r.NoError(c.RawQuery("INSERT INTO markers (name) VALUES ('primary')").Exec(nil))
+
+	type marker struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	markers := []marker{}
+
+	r.NoError(c.RawQuery("SELECT * FROM markers").All(nil, &markers))
+	r.Empty(markers)
+	count, err := c.RawQuery("SELECT * FROM markers").Count(nil, &marker{})
+	r.NoError(err)
+	r.Zero(count)
+	exists, err := c.RawQuery("SELECT * FROM markers").Exists(&marker{})
+	r.NoError(err)
+	r.False(exists)
+
+	r.NoError(c.RawQuery("SELECT * FROM markers").UsePrimary().All(nil, &markers))
+	r.Len(markers, 1)
+
+	r.NoError(c.Transaction(nil, func(tx *Connection) error {
+		m := marker{}
+		return tx.RawQuery("SELECT * FROM markers").First(nil, &m)
+	}))
+
+	c.replicas.replicas[0].healthy.Store(false)
+	r.NoError(c.RawQuery("SELECT * FROM markers").All(nil, &markers))
+	r.Len(markers, 1)
+}
+
+func Test_Query_RowLock_SQLite(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	err := PDB.Q().ForUpdate().First(nil, &User{})
+	r.EqualError(err, "sqlite3 does not support row locking clauses")
+
+	err = PDB.Q().ForShare().All(nil, &[]User{})
+	r.ErrorContains(err, "sqlite3 does not support row locking clauses")
+}
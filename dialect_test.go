@@ -32,3 +32,23 @@ func Test_genericDumpSchema(t *testing.T) {
 		})
 	}
 }
+
+func Test_onConflictDoUpdate(t *testing.T) {
+	r := require.New(t)
+	p := &postgresql{}
+
+	r.Equal(`ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name", "updated_at" = EXCLUDED."updated_at"`,
+		onConflictDoUpdate([]string{"email"}, []string{"name", "updated_at"}, p))
+	r.Equal(`ON CONFLICT ("org_id", "email") DO NOTHING`,
+		onConflictDoUpdate([]string{"org_id", "email"}, nil, p))
+}
+
+func Test_mysql_onDuplicateKeyUpdate(t *testing.T) {
+	r := require.New(t)
+	m := &mysql{}
+	model := &Model{Value: &Subscription{}}
+
+	r.Equal("ON DUPLICATE KEY UPDATE `plan` = VALUES(`plan`), `updated_at` = VALUES(`updated_at`)",
+		m.onDuplicateKeyUpdate(model, []string{"plan", "updated_at"}))
+	r.Equal("ON DUPLICATE KEY UPDATE `id` = `id`", m.onDuplicateKeyUpdate(model, nil))
+}
@@ -0,0 +1,191 @@
+package pop
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Accefy/pop/columns"
+	"github.com/gofrs/uuid"
+)
+
+// Tracked enables the tracking of the changes of a model when embedded in
+// it. The column values of the model are recorded when it is retrieved from
+// the database or saved, and `Changes` returns the columns which have been
+// modified since.
+//
+//	type User struct {
+//		pop.Tracked
+//		ID   int    `db:"id"`
+//		Name string `db:"name"`
+//	}
+type Tracked struct {
+	snapshot map[string]interface{} `db:"-"`
+}
+
+func (t *Tracked) tracked() *Tracked {
+	return t
+}
+
+type trackable interface {
+	tracked() *Tracked
+}
+
+// Change is the change of a column of a tracked model.
+type Change struct {
+	Column string
+	Old    interface{}
+	New    interface{}
+}
+
+// Changes returns the columns of a tracked model (see `Tracked`) which have
+// been modified since it was retrieved from the database or saved, sorted by
+// column name. It returns nil if the model is not tracked or has not been
+// retrieved or saved yet.
+func Changes(model interface{}) []Change {
+	changes, _ := NewModel(model, nil).changes()
+	return changes
+}
+
+// changes returns the changes of the model, and false if the model is not
+// tracked or has no snapshot yet.
+func (m *Model) changes() ([]Change, bool) {
+	t, ok := m.Value.(trackable)
+	if !ok || t.tracked().snapshot == nil {
+		return nil, false
+	}
+	snapshot := t.tracked().snapshot
+	v := reflect.Indirect(reflect.ValueOf(m.Value))
+
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		f := fieldMapper.FieldByName(v, name)
+		if !f.IsValid() {
+			continue
+		}
+		if cur := f.Interface(); !reflect.DeepEqual(snapshot[name], cur) {
+			changes = append(changes, Change{Column: name, Old: snapshot[name], New: cur})
+		}
+	}
+	return changes, true
+}
+
+// snapshot records the values of the given columns of a tracked model, or
+// of all its columns if none are given. Each element of a slice is recorded.
+func (m *Model) snapshot(names ...string) {
+	if m.isSlice() {
+		_ = m.iterate(func(m *Model) error {
+			m.snapshot(names...)
+			return nil
+		})
+		return
+	}
+
+	t, ok := m.Value.(trackable)
+	if !ok {
+		return
+	}
+	if len(names) == 0 {
+		for _, col := range m.Columns().Cols {
+			names = append(names, col.Name)
+		}
+	}
+
+	// The snapshot is replaced rather than updated in place: copies of the
+	// model share the map, and must keep their own snapshot.
+	tr := t.tracked()
+	snapshot := make(map[string]interface{}, len(tr.snapshot)+len(names))
+	for name, value := range tr.snapshot {
+		snapshot[name] = value
+	}
+	v := reflect.Indirect(reflect.ValueOf(m.Value))
+	for _, name := range names {
+		if f := fieldMapper.FieldByName(v, name); f.IsValid() {
+			snapshot[name] = f.Interface()
+		}
+	}
+	tr.snapshot = snapshot
+}
+
+// snapshotColumns records the values of the written columns of cols, and
+// of the version column of the model.
+func (m *Model) snapshotColumns(cols columns.Columns) {
+	var names []string
+	for name := range cols.Writeable().Cols {
+		names = append(names, name)
+	}
+	if vc := m.versionColumn(); vc != "" {
+		names = append(names, vc)
+	}
+	if len(names) > 0 {
+		m.snapshot(names...)
+	}
+}
+
+// UpdateChanged writes the columns of a tracked model (see `Tracked`) which
+// have been modified since it was retrieved from the database or saved. It
+// updates the `updated_at` column automatically, unless nothing changed.
+// All the columns are written if the model has not been retrieved or saved
+// yet, like `Update` does.
+//
+// If model is a slice, each item of the slice is updated in the database.
+func (c *Connection) UpdateChanged(requestID *uuid.UUID, model interface{}) error {
+	requestID = c.requestID(requestID)
+	sm := NewModel(model, c.Context())
+	return sm.iterate(func(m *Model) error {
+		return c.timeFunc("UpdateChanged", func() error {
+			var err error
+
+			if err = m.beforeSave(c); err != nil {
+				return err
+			}
+			if err = m.beforeUpdate(c); err != nil {
+				return err
+			}
+
+			cols := columns.ForStructWithAlias(m.Value, m.TableName(), m.As, m.IDField())
+			cols.Remove(m.IDField(), "created_at")
+
+			if changes, ok := m.changes(); ok {
+				changed := map[string]bool{}
+				for _, ch := range changes {
+					changed[ch.Column] = true
+				}
+				dirty := false
+				for name, col := range cols.Cols {
+					switch {
+					case changed[name]:
+						dirty = dirty || col.Writeable
+					case name != "updated_at":
+						cols.Remove(name)
+					}
+				}
+				if !dirty {
+					cols = columns.Columns{}
+				}
+			}
+
+			if len(cols.Cols) > 0 {
+				now := nowFunc().Truncate(time.Microsecond)
+				m.setUpdatedAt(now)
+
+				if err = c.Dialect.Update(c, requestID, m, cols); err != nil {
+					return c.translateError(m.TableName(), err)
+				}
+				m.snapshotColumns(cols)
+			}
+
+			if err = m.afterUpdate(c); err != nil {
+				return err
+			}
+
+			return m.afterSave(c)
+		})
+	})
+}
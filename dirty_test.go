@@ -0,0 +1,95 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Changes(t *testing.T) {
+	r := require.New(t)
+
+	s := &Subscription{Email: "mark@example.com", Plan: "free"}
+	r.Nil(Changes(s))
+	r.Nil(Changes(&User{}))
+
+	NewModel(s, nil).snapshot()
+	r.Empty(Changes(s))
+
+	s.Plan = "gold"
+	s.Callbacks = []string{"ignored"}
+	r.Equal([]Change{{Column: "plan", Old: "free", New: "gold"}}, Changes(s))
+
+	NewModel(s, nil).snapshot("plan")
+	r.Empty(Changes(s))
+}
+
+func Test_Changes_Copy(t *testing.T) {
+	r := require.New(t)
+
+	s := Subscription{Email: "mark@example.com", Plan: "free"}
+	NewModel(&s, nil).snapshot()
+
+	// a copy shares the snapshot until either of them is snapshotted again
+	c := s
+	c.Plan = "gold"
+	NewModel(&c, nil).snapshot()
+	r.Empty(Changes(&c))
+	r.Empty(Changes(&s))
+
+	s.Email = "mark@example.org"
+	NewModel(&s, nil).snapshot("email")
+	r.Empty(Changes(&s))
+	r.Empty(Changes(&c))
+
+	c.Plan = "silver"
+	r.Equal([]Change{{Column: "plan", Old: "gold", New: "silver"}}, Changes(&c))
+	s.Plan = "silver"
+	r.Equal([]Change{{Column: "plan", Old: "free", New: "silver"}}, Changes(&s))
+}
+
+func Test_UpdateChanged(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		s := &Subscription{Email: "mark@example.com", Plan: "free"}
+		r.NoError(tx.Create(nil, s))
+		r.Empty(Changes(s))
+
+		// a concurrent change of another column is not overwritten
+		r.NoError(tx.RawQuery("UPDATE subscriptions SET plan = ? WHERE id = ?", "gold", s.ID).Exec(nil))
+
+		s.Email = "mark@example.org"
+		r.Equal([]Change{{Column: "email", Old: "mark@example.com", New: "mark@example.org"}}, Changes(s))
+		r.NoError(tx.UpdateChanged(nil, s))
+		r.Empty(Changes(s))
+
+		found := &Subscription{}
+		r.NoError(tx.Find(nil, found, s.ID))
+		r.Equal("mark@example.org", found.Email)
+		r.Equal("gold", found.Plan)
+		r.Empty(Changes(found))
+
+		found.Plan = "silver"
+		r.Len(Changes(found), 1)
+
+		list := []Subscription{}
+		r.NoError(tx.All(nil, &list))
+		r.Len(list, 1)
+		r.Empty(Changes(&list[0]))
+		list[0].Plan = "bronze"
+		r.NoError(tx.UpdateChanged(nil, &list))
+		r.Empty(Changes(&list[0]))
+
+		r.NoError(tx.Reload(nil, found))
+		r.Equal("bronze", found.Plan)
+
+		// nothing changed, nothing is written
+		updatedAt := found.UpdatedAt
+		r.NoError(tx.UpdateChanged(nil, found))
+		r.Equal(updatedAt, found.UpdatedAt)
+	})
+}
@@ -0,0 +1,164 @@
+package pop
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when no record matches a query expecting one,
+// such as `Find` or `First`. The returned errors also match `sql.ErrNoRows`.
+var ErrNotFound = errors.New("record not found")
+
+// UniqueViolation is returned when a statement violates a unique constraint
+// or primary key.
+type UniqueViolation struct {
+	// Table the constraint belongs to, if reported by the database.
+	Table string
+	// Constraint is the name of the constraint, if reported by the database.
+	Constraint string
+	// Columns of the constraint, if reported by the database.
+	Columns []string
+	// Err is the original error of the driver.
+	Err error
+}
+
+func (e *UniqueViolation) Error() string {
+	return violationMessage("unique", e.Constraint, e.Columns, e.Err)
+}
+
+func (e *UniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ForeignKeyViolation is returned when a statement violates a foreign key
+// constraint.
+type ForeignKeyViolation struct {
+	// Table the constraint belongs to, if reported by the database.
+	Table string
+	// Constraint is the name of the constraint, if reported by the database.
+	Constraint string
+	// Columns of the constraint, if reported by the database.
+	Columns []string
+	// Err is the original error of the driver.
+	Err error
+}
+
+func (e *ForeignKeyViolation) Error() string {
+	return violationMessage("foreign key", e.Constraint, e.Columns, e.Err)
+}
+
+func (e *ForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+// CheckViolation is returned when a statement violates a check constraint.
+type CheckViolation struct {
+	// Table the constraint belongs to, if reported by the database.
+	Table string
+	// Constraint is the name of the constraint, if reported by the database.
+	Constraint string
+	// Err is the original error of the driver.
+	Err error
+}
+
+func (e *CheckViolation) Error() string {
+	return violationMessage("check", e.Constraint, nil, e.Err)
+}
+
+func (e *CheckViolation) Unwrap() error {
+	return e.Err
+}
+
+// NotNullViolation is returned when a statement sets a NOT NULL column to
+// NULL.
+type NotNullViolation struct {
+	// Table of the column, if reported by the database.
+	Table string
+	// Column is the name of the column, if reported by the database.
+	Column string
+	// Err is the original error of the driver.
+	Err error
+}
+
+func (e *NotNullViolation) Error() string {
+	var columns []string
+	if e.Column != "" {
+		columns = []string{e.Column}
+	}
+	return violationMessage("not null", "", columns, e.Err)
+}
+
+func (e *NotNullViolation) Unwrap() error {
+	return e.Err
+}
+
+func violationMessage(kind string, constraint string, columns []string, err error) string {
+	var b strings.Builder
+	b.WriteString(kind)
+	b.WriteString(" constraint violation")
+	if constraint != "" {
+		fmt.Fprintf(&b, " on %s", constraint)
+	}
+	if len(columns) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(columns, ", "))
+	}
+	if err != nil {
+		fmt.Fprintf(&b, ": %v", err)
+	}
+	return b.String()
+}
+
+// errorTranslatable is implemented by the dialects which turn the errors of
+// their driver into the typed errors of pop, such as `*UniqueViolation`.
+type errorTranslatable interface {
+	TranslateError(table string, err error) error
+}
+
+// translateError turns the error of a statement on the given table into the
+// typed errors of pop, if possible, and returns it unchanged otherwise.
+// Errors which have already been translated, by a nested statement for
+// instance, are returned as is.
+func (c *Connection) translateError(table string, err error) error {
+	if err == nil || isTranslated(err) {
+		return err
+	}
+	if t, ok := c.Dialect.(errorTranslatable); ok {
+		return t.TranslateError(table, err)
+	}
+	return err
+}
+
+func isTranslated(err error) bool {
+	var uv *UniqueViolation
+	var fkv *ForeignKeyViolation
+	var cv *CheckViolation
+	var nnv *NotNullViolation
+	return errors.As(err, &uv) || errors.As(err, &fkv) || errors.As(err, &cv) || errors.As(err, &nnv)
+}
+
+// notFoundError wraps the `sql.ErrNoRows` returned by a finder into
+// `ErrNotFound`, and returns any other error unchanged.
+func notFoundError(err error) error {
+	if !errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrNotFound, err)
+}
+
+// splitColumns splits a comma separated list of column names, removing the
+// quotes and table prefixes.
+func splitColumns(s string) []string {
+	var columns []string
+	for _, col := range strings.Split(s, ",") {
+		col = strings.Trim(strings.TrimSpace(col), "`\"")
+		if i := strings.LastIndex(col, "."); i >= 0 {
+			col = col[i+1:]
+		}
+		if col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,153 @@
+package pop
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	_mysql "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_translatePostgreSQLError(t *testing.T) {
+	r := require.New(t)
+
+	pgErr := &pgconn.PgError{Code: "23505", ConstraintName: "users_email_idx", Detail: "Key (email, name)=(a@b.c, Mark) already exists."}
+	err := translatePostgreSQLError("users", fmt.Errorf("wrapped: %w", pgErr))
+	var uv *UniqueViolation
+	r.True(errors.As(err, &uv))
+	r.Equal("users", uv.Table)
+	r.Equal("users_email_idx", uv.Constraint)
+	r.Equal([]string{"email", "name"}, uv.Columns)
+	r.True(errors.As(err, &pgErr))
+
+	err = translatePostgreSQLError("", &pq.Error{Code: "23503", Constraint: "books_user_id_fkey", Table: "books", Detail: `Key (user_id)=(5) is not present in table "users".`})
+	var fv *ForeignKeyViolation
+	r.True(errors.As(err, &fv))
+	r.Equal("books", fv.Table)
+	r.Equal([]string{"user_id"}, fv.Columns)
+
+	err = translatePostgreSQLError("books", &pgconn.PgError{Code: "23514", ConstraintName: "positive_pages"})
+	var cv *CheckViolation
+	r.True(errors.As(err, &cv))
+	r.Equal("positive_pages", cv.Constraint)
+
+	err = translatePostgreSQLError("books", &pgconn.PgError{Code: "23502", ColumnName: "title"})
+	var nv *NotNullViolation
+	r.True(errors.As(err, &nv))
+	r.Equal("title", nv.Column)
+
+	other := &pgconn.PgError{Code: "42P01"}
+	r.Equal(other, translatePostgreSQLError("books", other))
+}
+
+func Test_postgreSQLErrorCode(t *testing.T) {
+	r := require.New(t)
+
+	r.True(isSerializationFailure(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"})))
+	r.True(isSerializationFailure(&pq.Error{Code: "40001"}))
+	r.True(isUniqueViolation(&pq.Error{Code: "23505"}))
+	r.False(isSerializationFailure(&pq.Error{Code: "23505"}))
+	r.False(isUniqueViolation(errors.New("duplicate key")))
+}
+
+func Test_notFoundError(t *testing.T) {
+	r := require.New(t)
+
+	err := notFoundError(fmt.Errorf("select one: %w", sql.ErrNoRows))
+	r.True(errors.Is(err, ErrNotFound))
+	r.True(errors.Is(err, sql.ErrNoRows))
+	r.Equal(err, notFoundError(err))
+
+	other := errors.New("connection refused")
+	r.Equal(other, notFoundError(other))
+}
+
+func Test_mysql_TranslateError(t *testing.T) {
+	r := require.New(t)
+	m := &mysql{}
+
+	err := m.TranslateError("users", &_mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.users_email_idx'"})
+	var uv *UniqueViolation
+	r.True(errors.As(err, &uv))
+	r.Equal("users_email_idx", uv.Constraint)
+
+	err = m.TranslateError("books", &_mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`pop_test`.`books`, CONSTRAINT `books_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"})
+	var fv *ForeignKeyViolation
+	r.True(errors.As(err, &fv))
+	r.Equal("books", fv.Table)
+	r.Equal("books_user_id_fk", fv.Constraint)
+	r.Equal([]string{"user_id"}, fv.Columns)
+
+	err = m.TranslateError("books", &_mysql.MySQLError{Number: 3819, Message: "Check constraint 'positive_pages' is violated."})
+	var cv *CheckViolation
+	r.True(errors.As(err, &cv))
+	r.Equal("positive_pages", cv.Constraint)
+
+	err = m.TranslateError("books", &_mysql.MySQLError{Number: 4025, Message: "CONSTRAINT `positive_pages` failed for `pop_test`.`books`"})
+	r.True(errors.As(err, &cv))
+	r.Equal("positive_pages", cv.Constraint)
+
+	err = m.TranslateError("books", &_mysql.MySQLError{Number: 1048, Message: "Column 'title' cannot be null"})
+	var nv *NotNullViolation
+	r.True(errors.As(err, &nv))
+	r.Equal("title", nv.Column)
+}
+
+func Test_sqlite_TranslateError(t *testing.T) {
+	r := require.New(t)
+	m := &sqlite{}
+
+	err := m.TranslateError("", errors.New("UNIQUE constraint failed: users.email, users.name"))
+	var uv *UniqueViolation
+	r.True(errors.As(err, &uv))
+	r.Equal("users", uv.Table)
+	r.Equal([]string{"email", "name"}, uv.Columns)
+
+	err = m.TranslateError("books", errors.New("FOREIGN KEY constraint failed"))
+	var fv *ForeignKeyViolation
+	r.True(errors.As(err, &fv))
+	r.Equal("books", fv.Table)
+
+	err = m.TranslateError("books", errors.New("CHECK constraint failed: positive_pages"))
+	var cv *CheckViolation
+	r.True(errors.As(err, &cv))
+	r.Equal("positive_pages", cv.Constraint)
+
+	err = m.TranslateError("", errors.New("NOT NULL constraint failed: books.title"))
+	var nv *NotNullViolation
+	r.True(errors.As(err, &nv))
+	r.Equal("books", nv.Table)
+	r.Equal("title", nv.Column)
+
+	other := errors.New("no such table: books")
+	r.Equal(other, m.TranslateError("books", other))
+}
+
+func Test_TypedErrors(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		err := tx.Find(nil, &User{}, -1)
+		r.True(errors.Is(err, ErrNotFound))
+		r.True(errors.Is(err, sql.ErrNoRows))
+
+		r.NoError(tx.Create(nil, &Subscription{Email: "mark@example.com", Plan: "free"}))
+		err = tx.Create(nil, &Subscription{Email: "mark@example.com", Plan: "gold"})
+		var uv *UniqueViolation
+		r.True(errors.As(err, &uv), "%v", err)
+		r.Equal("subscriptions", uv.Table)
+
+		err = tx.RawQuery("INSERT INTO subscriptions (email, plan, created_at, updated_at) VALUES (NULL, ?, ?, ?)", "free", time.Now(), time.Now()).Exec(nil)
+		var nv *NotNullViolation
+		r.True(errors.As(err, &nv), "%v", err)
+		r.Equal("email", nv.Column)
+	})
+}
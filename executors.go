@@ -1,8 +1,10 @@
 package pop
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/Accefy/pop/associations"
@@ -12,11 +14,12 @@ import (
 	"github.com/gofrs/uuid"
 )
 
-// Reload fetch fresh data for a given model, using its ID.
+// Reload fetch fresh data for a given model, using its ID. The data is read
+// from the primary database, since the model has usually just been written.
 func (c *Connection) Reload(requestID *uuid.UUID, model interface{}) error {
 	sm := NewModel(model, c.Context())
 	return sm.iterate(func(m *Model) error {
-		return c.Find(requestID, m.Value, m.ID())
+		return Q(c).UsePrimary().Find(requestID, m.Value, m.ID())
 	})
 }
 
@@ -24,36 +27,48 @@ func (c *Connection) Reload(requestID *uuid.UUID, model interface{}) error {
 
 // Exec runs the given query.
 func (q *Query) Exec(requestID *uuid.UUID) error {
+	requestID = q.Connection.requestID(requestID)
 	return q.Connection.timeFunc("Exec", func() error {
-		sql, args := q.ToSQL(nil)
+		sql, args, err := q.toSQL(nil)
+		if err != nil {
+			return err
+		}
 		if sql == "" {
 			return fmt.Errorf("empty query")
 		}
 
-		txlog(logging.SQL, requestID, q.Connection, sql, args...)
-		_, err := q.Connection.Store.Exec(sql, args...)
-		return err
+		err = logSQL(requestID, q.Connection, "", sql, args, func() error {
+			_, err := q.Connection.Store.Exec(sql, args...)
+			return err
+		})
+		return q.Connection.translateError("", err)
 	})
 }
 
 // ExecWithCount runs the given query, and returns the amount of
 // affected rows.
 func (q *Query) ExecWithCount(requestID *uuid.UUID) (int, error) {
+	requestID = q.Connection.requestID(requestID)
 	count := int64(0)
 	return int(count), q.Connection.timeFunc("Exec", func() error {
-		sql, args := q.ToSQL(nil)
+		sql, args, err := q.toSQL(nil)
+		if err != nil {
+			return err
+		}
 		if sql == "" {
 			return fmt.Errorf("empty query")
 		}
 
-		txlog(logging.SQL, requestID, q.Connection, sql, args...)
-		result, err := q.Connection.Store.Exec(sql, args...)
-		if err != nil {
-			return err
-		}
+		err = logSQL(requestID, q.Connection, "", sql, args, func() error {
+			result, err := q.Connection.Store.Exec(sql, args...)
+			if err != nil {
+				return err
+			}
 
-		count, err = result.RowsAffected()
-		return err
+			count, err = result.RowsAffected()
+			return err
+		})
+		return q.Connection.translateError("", err)
 	})
 }
 
@@ -95,7 +110,7 @@ func (c *Connection) Save(requestID *uuid.UUID, model interface{}, excludeColumn
 			return err
 		}
 		if IsZeroOfUnderlyingType(id.Interface()) {
-			return c.Create(nil, m.Value, excludeColumns...)
+			return c.Create(requestID, m.Value, excludeColumns...)
 		}
 		return c.Update(requestID, m.Value, excludeColumns...)
 	})
@@ -183,6 +198,7 @@ func (c *Connection) ValidateAndCreate(requestID *uuid.UUID, model interface{},
 // * Flat (default): Associate existing nested objects only. NO creation or update of nested objects.
 // * Eager: Associate existing nested objects and create non-existent objects. NO change to existing objects.
 func (c *Connection) Create(requestID *uuid.UUID, model interface{}, excludeColumns ...string) error {
+	requestID = c.requestID(requestID)
 	var isEager = c.eager
 
 	c.disableEager()
@@ -256,8 +272,9 @@ func (c *Connection) Create(requestID *uuid.UUID, model interface{}, excludeColu
 			m.setCreatedAt(now)
 
 			if err = c.Dialect.Create(c, requestID, m, cols); err != nil {
-				return err
+				return c.translateError(m.TableName(), err)
 			}
+			m.snapshot()
 
 			if processAssoc {
 				after := asos.AssociationsAfterCreatable()
@@ -325,6 +342,58 @@ func (c *Connection) Create(requestID *uuid.UUID, model interface{}, excludeColu
 	})
 }
 
+// CreateMany adds new entries to the database with multi-row INSERT
+// statements, instead of one statement per entry as Create does. The number
+// of rows per statement is set by the "bulk_insert_batch_size" option. UUID
+// IDs are generated as needed, and integer IDs are read back from the
+// database. The `created_at` and `updated_at` columns are set automatically.
+//
+// CreateMany runs the (before|after)(Save|Create) callbacks, but does not
+// handle associations. Run it in a transaction for the entries to be inserted
+// all together or not at all.
+//
+//	c.CreateMany(nil, &[]User{{Name: "Mark"}, {Name: "Larry"}})
+func (c *Connection) CreateMany(requestID *uuid.UUID, models interface{}, excludeColumns ...string) error {
+	requestID = c.requestID(requestID)
+	sm := NewModel(models, c.Context())
+	return c.timeFunc("CreateMany", func() error {
+		var ms []*Model
+		now := nowFunc().Truncate(time.Microsecond)
+		err := sm.iterate(func(m *Model) error {
+			if err := m.beforeSave(c); err != nil {
+				return err
+			}
+			if err := m.beforeCreate(c); err != nil {
+				return err
+			}
+			m.setUpdatedAt(now)
+			m.setCreatedAt(now)
+			ms = append(ms, m)
+			return nil
+		})
+		if err != nil || len(ms) == 0 {
+			return err
+		}
+
+		cols := ms[0].Columns()
+		cols.Remove(excludeColumns...)
+		if err := c.Dialect.CreateMany(c, requestID, ms, cols); err != nil {
+			return c.translateError(ms[0].TableName(), err)
+		}
+
+		for _, m := range ms {
+			m.snapshot()
+			if err := m.afterCreate(c); err != nil {
+				return err
+			}
+			if err := m.afterSave(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // ValidateAndUpdate applies validation rules on the given entry, then update it
 // if the validation succeed, excluding the given columns.
 //
@@ -349,6 +418,7 @@ func (c *Connection) ValidateAndUpdate(requestID *uuid.UUID, model interface{},
 //
 // If model is a slice, each item of the slice is updated in the database.
 func (c *Connection) Update(requestID *uuid.UUID, model interface{}, excludeColumns ...string) error {
+	requestID = c.requestID(requestID)
 	sm := NewModel(model, c.Context())
 	return sm.iterate(func(m *Model) error {
 		return c.timeFunc("Update", func() error {
@@ -373,8 +443,9 @@ func (c *Connection) Update(requestID *uuid.UUID, model interface{}, excludeColu
 			m.setUpdatedAt(now)
 
 			if err = c.Dialect.Update(c, requestID, m, cols); err != nil {
-				return err
+				return c.translateError(m.TableName(), err)
 			}
+			m.snapshotColumns(cols)
 			if err = m.afterUpdate(c); err != nil {
 				return err
 			}
@@ -410,7 +481,8 @@ func (q *Query) UpdateQuery(requestID *uuid.UUID, model interface{}, columnNames
 
 	now := nowFunc().Truncate(time.Microsecond)
 	sm.setUpdatedAt(now)
-	return q.Connection.Dialect.UpdateQuery(q.Connection, requestID, sm, cols, *q)
+	n, err := q.Connection.Dialect.UpdateQuery(q.Connection, q.Connection.requestID(requestID), sm, cols, *q)
+	return n, q.Connection.translateError(sm.TableName(), err)
 }
 
 // UpdateColumns writes changes from an entry to the database, including only the given columns
@@ -419,6 +491,7 @@ func (q *Query) UpdateQuery(requestID *uuid.UUID, model interface{}, columnNames
 //
 // If model is a slice, each item of the slice is updated in the database.
 func (c *Connection) UpdateColumns(requestID *uuid.UUID, model interface{}, columnNames ...string) error {
+	requestID = c.requestID(requestID)
 	sm := NewModel(model, c.Context())
 	return sm.iterate(func(m *Model) error {
 		return c.timeFunc("Update", func() error {
@@ -447,8 +520,9 @@ func (c *Connection) UpdateColumns(requestID *uuid.UUID, model interface{}, colu
 			m.setUpdatedAt(now)
 
 			if err = c.Dialect.Update(c, requestID, m, cols); err != nil {
-				return err
+				return c.translateError(m.TableName(), err)
 			}
+			m.snapshotColumns(cols)
 			if err = m.afterUpdate(c); err != nil {
 				return err
 			}
@@ -458,11 +532,79 @@ func (c *Connection) UpdateColumns(requestID *uuid.UUID, model interface{}, colu
 	})
 }
 
+// Upsert inserts an entry into the database or, if it conflicts with an
+// existing row on the given unique columns, updates the given columns of that
+// row instead. If no update columns are given, all the columns but the
+// conflict columns, ID and CreatedAt are updated. The `updated_at` column is
+// updated automatically.
+//
+// The ID and timestamps of the model are refreshed from the inserted or
+// updated row. Upsert runs the (before|after)Save callbacks, but not the
+// Create and Update ones, and does not handle associations.
+//
+// If model is a slice, each item of the slice is upserted in the database.
+//
+//	c.Upsert(nil, &user, []string{"email"}, "name")
+func (c *Connection) Upsert(requestID *uuid.UUID, model interface{}, conflictColumns []string, updateColumns ...string) error {
+	requestID = c.requestID(requestID)
+	if len(conflictColumns) == 0 {
+		return errors.New("upsert needs at least one conflict column")
+	}
+	sm := NewModel(model, c.Context())
+	return sm.iterate(func(m *Model) error {
+		return c.timeFunc("Upsert", func() error {
+			var err error
+
+			if err = m.beforeSave(c); err != nil {
+				return err
+			}
+
+			cols := m.Columns()
+
+			update := columns.NewColumnsWithAlias(m.TableName(), m.As, m.IDField())
+			if len(updateColumns) > 0 {
+				update.Add(updateColumns...)
+				if _, err := m.fieldByName("UpdatedAt"); err == nil {
+					update.Add("updated_at")
+				}
+			} else {
+				for name := range cols.Writeable().Cols {
+					update.Add(name)
+				}
+				update.Remove(conflictColumns...)
+			}
+			update.Remove(m.IDField(), "created_at")
+
+			names := make([]string, 0, len(update.Cols))
+			for name := range update.Cols {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			now := nowFunc().Truncate(time.Microsecond)
+			m.setUpdatedAt(now)
+			m.setCreatedAt(now)
+
+			if err = c.Dialect.Upsert(c, requestID, m, cols, conflictColumns, names); err != nil {
+				return c.translateError(m.TableName(), err)
+			}
+			m.snapshot()
+
+			return m.afterSave(c)
+		})
+	})
+}
+
 // Destroy deletes a given entry from the database.
 //
+// Soft deletable models (see `SoftDeletable`) are not deleted, their
+// `deleted_at` column is set instead. Use `HardDestroy` to delete them.
+//
 // If model is a slice, each item of the slice is deleted from the database.
 func (c *Connection) Destroy(requestID *uuid.UUID, model interface{}) error {
+	requestID = c.requestID(requestID)
 	sm := NewModel(model, c.Context())
+	column := sm.deletedAtColumn()
 	return sm.iterate(func(m *Model) error {
 		return c.timeFunc("Destroy", func() error {
 			var err error
@@ -470,7 +612,13 @@ func (c *Connection) Destroy(requestID *uuid.UUID, model interface{}) error {
 			if err = m.beforeDestroy(c); err != nil {
 				return err
 			}
-			if err = c.Dialect.Destroy(c, requestID, m); err != nil {
+			if column != "" {
+				now := nowFunc().Truncate(time.Microsecond)
+				err = c.updateDeletedAt(requestID, m, column, &now)
+			} else {
+				err = c.translateError(m.TableName(), c.Dialect.Destroy(c, requestID, m))
+			}
+			if err != nil {
 				return err
 			}
 
@@ -481,12 +629,13 @@ func (c *Connection) Destroy(requestID *uuid.UUID, model interface{}) error {
 
 func (q *Query) Delete(requestID *uuid.UUID, model interface{}) error {
 	q.Operation = Delete
+	requestID = q.Connection.requestID(requestID)
 
 	return q.Connection.timeFunc("Delete", func() error {
 		m := NewModel(model, q.Connection.Context())
 		err := q.Connection.Dialect.Delete(q.Connection, requestID, m, *q)
 		if err != nil {
-			return err
+			return q.Connection.translateError(m.TableName(), err)
 		}
 		return m.afterDestroy(q.Connection)
 	})
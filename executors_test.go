@@ -1516,6 +1516,145 @@ func Test_UpdateColumns(t *testing.T) {
 	})
 }
 
+func Test_CreateMany(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	deets := PDB.Dialect.Details()
+	deets.setOption("bulk_insert_batch_size", "2")
+	defer deets.setOption("bulk_insert_batch_size", "")
+
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		users := []User{
+			{Name: nulls.NewString("Mark")},
+			{Name: nulls.NewString("Larry")},
+			{Name: nulls.NewString("Sarah")},
+		}
+		r.NoError(tx.CreateMany(nil, &users))
+
+		for _, u := range users {
+			r.NotZero(u.ID)
+			r.NotZero(u.CreatedAt)
+			r.NotZero(u.UpdatedAt)
+
+			found := User{}
+			r.NoError(tx.Find(nil, &found, u.ID))
+			r.Equal(u.Name, found.Name)
+		}
+
+		songs := []Song{{Title: "A"}, {Title: "B"}, {Title: "C"}}
+		r.NoError(tx.CreateMany(nil, &songs))
+		for _, s := range songs {
+			r.NotEqual(uuid.Nil, s.ID)
+			found := Song{}
+			r.NoError(tx.Find(nil, &found, s.ID))
+			r.Equal(s.Title, found.Title)
+		}
+
+		subs := []Subscription{{Email: "a@example.com"}, {Email: "b@example.com"}}
+		r.NoError(tx.CreateMany(nil, &subs))
+		r.Equal([]string{"BeforeSave", "AfterSave"}, subs[0].Callbacks)
+		r.Equal([]string{"BeforeSave", "AfterSave"}, subs[1].Callbacks)
+	})
+}
+
+func Test_CreateMany_Batches(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	deets := PDB.Dialect.Details()
+	old := deets.Options
+	deets.Options = map[string]string{}
+	for k, v := range old {
+		deets.Options[k] = v
+	}
+	deets.Options["bulk_insert_batch_size"] = "2"
+	defer func() { deets.Options = old }()
+
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		names := []string{"Mark", "Larry", "Sarah", "Jane", "Ann"}
+		users := make([]User, len(names))
+		for i, name := range names {
+			users[i].Name = nulls.NewString(name)
+		}
+		r.NoError(tx.CreateMany(nil, &users))
+		for i, u := range users {
+			r.NotZero(u.ID)
+			if i > 0 {
+				r.Greater(u.ID, users[i-1].ID)
+			}
+			found := User{}
+			r.NoError(tx.Find(nil, &found, u.ID))
+			r.Equal(names[i], found.Name.String)
+		}
+	})
+}
+
+func Test_CreateMany_AutoIncrementIncrement(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	if PDB.Dialect.Name() != nameMySQL && PDB.Dialect.Name() != nameMariaDB {
+		t.Skip("auto_increment_increment is specific to MySQL")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+		r.NoError(tx.RawQuery("SET SESSION auto_increment_increment = 3").Exec(nil))
+		defer tx.RawQuery("SET SESSION auto_increment_increment = 1").Exec(nil)
+
+		users := []User{
+			{Name: nulls.NewString("Mark")},
+			{Name: nulls.NewString("Larry")},
+			{Name: nulls.NewString("Sarah")},
+		}
+		r.NoError(tx.CreateMany(nil, &users))
+		r.Equal(users[0].ID+3, users[1].ID)
+		for _, u := range users {
+			found := User{}
+			r.NoError(tx.Find(nil, &found, u.ID))
+			r.Equal(u.Name, found.Name)
+		}
+	})
+}
+
+func Test_Upsert(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		first := Subscription{Email: "mark@example.com", Plan: "free"}
+		r.NoError(tx.Upsert(nil, &first, []string{"email"}))
+		r.NotZero(first.ID)
+		r.NotZero(first.CreatedAt)
+		r.Equal([]string{"BeforeSave", "AfterSave"}, first.Callbacks)
+
+		second := Subscription{Email: "mark@example.com", Plan: "pro"}
+		r.NoError(tx.Upsert(nil, &second, []string{"email"}))
+		r.Equal(first.ID, second.ID)
+		r.Equal(first.CreatedAt.Unix(), second.CreatedAt.Unix())
+
+		third := Subscription{Email: "mark@example.com", Plan: "enterprise"}
+		r.NoError(tx.Upsert(nil, &third, []string{"email"}, "updated_at"))
+		r.Equal(first.ID, third.ID)
+
+		count, err := tx.Count(nil, &Subscription{})
+		r.NoError(err)
+		r.Equal(1, count)
+
+		reloaded := Subscription{}
+		r.NoError(tx.Find(nil, &reloaded, first.ID))
+		r.Equal("pro", reloaded.Plan)
+
+		r.Error(tx.Upsert(nil, &Subscription{Email: "x"}, nil))
+	})
+}
+
 func Test_UpdateQuery_NoUpdatedAt(t *testing.T) {
 	if PDB == nil {
 		t.Skip("skipping integration tests")
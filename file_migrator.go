@@ -10,7 +10,8 @@ import (
 )
 
 // FileMigrator is a migrator for SQL and Fizz
-// files on disk at a specified path.
+// files on disk at a specified path, and for the
+// migrations added with RegisterMigration.
 type FileMigrator struct {
 	Migrator
 	Path string
@@ -24,27 +25,20 @@ func NewFileMigrator(path string, c *Connection) (FileMigrator, error) {
 	}
 	fm.SchemaPath = path
 
-	runner := func(mf Migration, tx *Connection) error {
+	content := func(mf Migration, tx *Connection) (string, error) {
 		f, err := os.Open(mf.Path)
 		if err != nil {
-			return err
+			return "", err
 		}
 		defer f.Close()
 		content, err := MigrationContent(mf, tx, f, true)
 		if err != nil {
-			return fmt.Errorf("error processing %s: %w", mf.Path, err)
+			return "", fmt.Errorf("error processing %s: %w", mf.Path, err)
 		}
-		if content == "" {
-			return nil
-		}
-		err = tx.RawQuery(content).Exec(nil)
-		if err != nil {
-			return fmt.Errorf("error executing %s, sql: %s: %w", mf.Path, content, err)
-		}
-		return nil
+		return content, nil
 	}
 
-	err := fm.findMigrations(runner)
+	err := fm.findMigrations(content)
 	if err != nil {
 		return fm, err
 	}
@@ -52,7 +46,7 @@ func NewFileMigrator(path string, c *Connection) (FileMigrator, error) {
 	return fm, nil
 }
 
-func (fm *FileMigrator) findMigrations(runner func(mf Migration, tx *Connection) error) error {
+func (fm *FileMigrator) findMigrations(content func(mf Migration, tx *Connection) (string, error)) error {
 	dir := fm.Path
 	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
 		// directory doesn't exist
@@ -72,14 +66,22 @@ func (fm *FileMigrator) findMigrations(runner func(mf Migration, tx *Connection)
 				log(logging.Warn, nil, "ignoring file %s because it does not match the migration file pattern", info.Name())
 				return nil
 			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			_, noTransaction := cutNoTransactionMarker(b)
 			mf := Migration{
-				Path:      p,
-				Version:   match.Version,
-				Name:      match.Name,
-				DBType:    match.DBType,
-				Direction: match.Direction,
-				Type:      match.Type,
-				Runner:    runner,
+				Path:          p,
+				Version:       match.Version,
+				Name:          match.Name,
+				DBType:        match.DBType,
+				Direction:     match.Direction,
+				Type:          match.Type,
+				Checksum:      migrationChecksum(b),
+				NoTransaction: noTransaction,
+				Content:       content,
+				Runner:        runMigrationContent,
 			}
 			switch mf.Direction {
 			case "up":
@@ -12,6 +12,7 @@ import (
 	"github.com/Accefy/pop/associations"
 	"github.com/Accefy/pop/logging"
 	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 var rLimitOffset = regexp.MustCompile("(?i)(limit [0-9]+ offset [0-9]+)$")
@@ -66,12 +67,16 @@ func (c *Connection) First(requestID *uuid.UUID, model interface{}) error {
 //
 //	q.Where("name = ?", "mark").First(&User{})
 func (q *Query) First(requestID *uuid.UUID, model interface{}) error {
+	requestID = q.Connection.requestID(requestID)
 	var m *Model
 	err := q.Connection.timeFunc("First", func() error {
+		if err := q.checkRowLock(); err != nil {
+			return err
+		}
 		q.Limit(1)
 		m = NewModel(model, q.Connection.Context())
-		if err := q.Connection.Dialect.SelectOne(q.Connection, requestID, m, *q); err != nil {
-			return err
+		if err := q.Connection.Dialect.SelectOne(q.readConnection(), requestID, m, *q); err != nil {
+			return notFoundError(err)
 		}
 		return m.afterFind(q.Connection, false)
 	})
@@ -103,13 +108,17 @@ func (c *Connection) Last(requestID *uuid.UUID, model interface{}) error {
 //
 //	q.Where("name = ?", "mark").Last(&User{})
 func (q *Query) Last(requestID *uuid.UUID, model interface{}) error {
+	requestID = q.Connection.requestID(requestID)
 	var m *Model
 	err := q.Connection.timeFunc("Last", func() error {
+		if err := q.checkRowLock(); err != nil {
+			return err
+		}
 		q.Limit(1)
 		q.Order("created_at DESC, id DESC")
 		m = NewModel(model, q.Connection.Context())
-		if err := q.Connection.Dialect.SelectOne(q.Connection, requestID, m, *q); err != nil {
-			return err
+		if err := q.Connection.Dialect.SelectOne(q.readConnection(), requestID, m, *q); err != nil {
+			return notFoundError(err)
 		}
 		return m.afterFind(q.Connection, false)
 	})
@@ -141,10 +150,17 @@ func (c *Connection) All(requestID *uuid.UUID, models interface{}) error {
 //
 //	q.Where("name = ?", "mark").All(&[]User{})
 func (q *Query) All(requestID *uuid.UUID, models interface{}) error {
+	requestID = q.Connection.requestID(requestID)
+	if q.CursorPaginator != nil {
+		return q.allWithCursor(requestID, models)
+	}
 	var m *Model
 	err := q.Connection.timeFunc("All", func() error {
+		if err := q.checkRowLock(); err != nil {
+			return err
+		}
 		m = NewModel(models, q.Connection.Context())
-		err := q.Connection.Dialect.SelectMany(q.Connection, requestID, m, *q)
+		err := q.Connection.Dialect.SelectMany(q.readConnection(), requestID, m, *q)
 		if err != nil {
 			return err
 		}
@@ -272,7 +288,10 @@ func (q *Query) eagerDefaultAssociations(model interface{}) error {
 			}
 		}
 
-		sqlSentence, args := query.ToSQL(NewModel(association.Interface(), query.Connection.Context()))
+		sqlSentence, args, err := query.toSQL(NewModel(association.Interface(), query.Connection.Context()))
+		if err != nil {
+			return err
+		}
 		query = query.RawQuery(sqlSentence, args...)
 
 		if association.Kind() == reflect.Slice || association.Kind() == reflect.Array {
@@ -287,7 +306,7 @@ func (q *Query) eagerDefaultAssociations(model interface{}) error {
 			return err
 		}
 
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			continue
 		}
 
@@ -327,7 +346,12 @@ func (q *Query) Exists(model interface{}) (bool, error) {
 		tmpQuery.Paginator = nil
 		tmpQuery.orderClauses = clauses{}
 		tmpQuery.limitResults = 0
-		query, args := tmpQuery.ToSQL(NewModel(model, tmpQuery.Connection.Context()))
+		tmpQuery.rowLock = nil
+		m := NewModel(model, tmpQuery.Connection.Context())
+		query, args, err := tmpQuery.toSQL(m)
+		if err != nil {
+			return err
+		}
 
 		// when query contains custom selected fields / executed using RawQuery,
 		// sql may already contains limit and offset
@@ -340,8 +364,10 @@ func (q *Query) Exists(model interface{}) (bool, error) {
 		}
 
 		existsQuery := fmt.Sprintf("SELECT EXISTS (%s)", query)
-		txlog(logging.SQL, nil, q.Connection, existsQuery, args...)
-		return q.Connection.Store.Get(&res, existsQuery, args...)
+		c := q.readConnection()
+		return logSQL(q.Connection.requestID(nil), c, logTableName(m), existsQuery, args, func() error {
+			return c.Store.Get(&res, existsQuery, args...)
+		})
 	})
 	return res, err
 }
@@ -364,6 +390,7 @@ func (q Query) Count(requestID *uuid.UUID, model interface{}) (int, error) {
 //
 //	q.Where("sex = ?", "f").Count(&User{}, "name")
 func (q Query) CountByField(requestID *uuid.UUID, model interface{}, field string) (int, error) {
+	requestID = q.Connection.requestID(requestID)
 	tmpQuery := Q(q.Connection)
 	q.Clone(tmpQuery) // avoid meddling with original query
 
@@ -373,7 +400,12 @@ func (q Query) CountByField(requestID *uuid.UUID, model interface{}, field strin
 		tmpQuery.Paginator = nil
 		tmpQuery.orderClauses = clauses{}
 		tmpQuery.limitResults = 0
-		query, args := tmpQuery.ToSQL(NewModel(model, q.Connection.Context()))
+		tmpQuery.rowLock = nil
+		m := NewModel(model, q.Connection.Context())
+		query, args, err := tmpQuery.toSQL(m)
+		if err != nil {
+			return err
+		}
 		// when query contains custom selected fields / executed using RawQuery,
 		//	sql may already contains limit and offset
 
@@ -386,8 +418,10 @@ func (q Query) CountByField(requestID *uuid.UUID, model interface{}, field strin
 		}
 
 		countQuery := fmt.Sprintf("SELECT COUNT(%s) AS row_count FROM (%s) a", field, query)
-		txlog(logging.SQL, requestID, q.Connection, countQuery, args...)
-		return q.Connection.Store.Get(res, countQuery, args...)
+		c := q.readConnection()
+		return logSQL(requestID, c, logTableName(m), countQuery, args, func() error {
+			return c.Store.Get(res, countQuery, args...)
+		})
 	})
 	return res.Count, err
 }
@@ -414,3 +448,150 @@ func (q *Query) Select(fields ...string) *Query {
 	}
 	return q
 }
+
+// Each streams the records in the database that match the query, one at a
+// time, instead of loading them all in memory as All does. Every record is
+// read into model, which must be a pointer to a struct, before fn is called
+// with it. Iteration stops at the first error returned by fn.
+//
+// The connection the records are read from stays busy until the iteration
+// ends. In a transaction, fn and the AfterFind callbacks of the model must
+// not query the database, which most drivers refuse while the records are
+// read. For the same reason, Each does not support eager loading: use
+// FindInBatches to process records along their associations.
+//
+//	q.Where("active = ?", true).Each(nil, &User{}, func(m interface{}) error {
+//		u := m.(*User)
+//		return export(u)
+//	})
+func (q *Query) Each(requestID *uuid.UUID, model interface{}, fn func(model interface{}) error) error {
+	requestID = q.Connection.requestID(requestID)
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("model must be a pointer to a struct; got %T", model)
+	}
+	if err := q.checkRowLock(); err != nil {
+		return err
+	}
+	if q.eager {
+		q.disableEager()
+		return errors.New("Each does not support eager loading, use FindInBatches instead")
+	}
+
+	ctx := q.Connection.Context()
+	c := q.readConnection()
+	m := NewModel(model, ctx)
+	query, args, err := q.toSQL(m)
+	if err != nil {
+		return err
+	}
+
+	// The slow query report is deferred until the rows are closed, since it
+	// may query the plan of the statement on the same connection.
+	var rows *sqlx.Rows
+	elapsed, err := runSQL(requestID, c, logTableName(m), query, args, func() error {
+		var err error
+		rows, err = c.Store.QueryxContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		c.logSlowQuery(requestID, logTableName(m), query, args, elapsed, err)
+		return fmt.Errorf("unable to fetch records: %w", err)
+	}
+	defer c.logSlowQuery(requestID, logTableName(m), query, args, elapsed, nil)
+	defer rows.Close()
+
+	zero := reflect.Zero(v.Elem().Type())
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v.Elem().Set(zero)
+		if err := rows.StructScan(model); err != nil {
+			return fmt.Errorf("unable to scan record: %w", err)
+		}
+		if err := m.afterFind(q.Connection, false); err != nil {
+			return err
+		}
+		if err := fn(model); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindInBatches walks the records in the database that match the query in
+// batches of the given size, ordered by primary key. Each batch is read into
+// models, which must be a pointer to a slice, before fn is called with it.
+// Iteration stops at the first error returned by fn.
+//
+// Batches are selected by primary key rather than by offset, so records can
+// be safely updated or deleted by fn. Any order clause of the query is
+// ignored.
+//
+//	q.Where("active = ?", true).FindInBatches(nil, 1000, &[]User{}, func(m interface{}) error {
+//		users := m.(*[]User)
+//		return export(*users)
+//	})
+func (q *Query) FindInBatches(requestID *uuid.UUID, size int, models interface{}, fn func(models interface{}) error) error {
+	requestID = q.Connection.requestID(requestID)
+	if size < 1 {
+		return fmt.Errorf("batch size must be positive; got %d", size)
+	}
+	v := reflect.ValueOf(models)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("models must be a pointer to a slice; got %T", models)
+	}
+	if q.RawSQL.Fragment != "" {
+		return errors.New("FindInBatches can not be used with raw SQL queries")
+	}
+	if len(q.orderClauses) > 0 {
+		log(logging.Warn, requestID, "FindInBatches ignores the order clauses of the query")
+	}
+
+	ctx := q.Connection.Context()
+	m := NewModel(models, ctx)
+	idColumn := fmt.Sprintf("%s.%s", m.Alias(), m.IDField())
+
+	var last interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bq := Q(q.Connection)
+		q.Clone(bq)
+		bq.eager = q.eager
+		bq.eagerFields = q.eagerFields
+		bq.eagerMode = q.eagerMode
+		bq.Paginator = nil
+		bq.CursorPaginator = nil
+		bq.orderClauses = clauses{}
+		if last != nil {
+			bq.Where(idColumn+" > ?", last)
+		}
+		bq.Order(idColumn + " ASC").Limit(size)
+
+		v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), 0, size))
+		if err := bq.All(requestID, models); err != nil {
+			return err
+		}
+
+		n := v.Elem().Len()
+		if n == 0 {
+			return nil
+		}
+		// the cursor is read before fn, which may modify the batch
+		e := v.Elem().Index(n - 1)
+		if e.Kind() != reflect.Ptr {
+			e = e.Addr()
+		}
+		last = NewModel(e.Interface(), ctx).ID()
+		if err := fn(models); err != nil {
+			return err
+		}
+		if n < size {
+			return nil
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package pop
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/gobuffalo/nulls"
@@ -606,6 +609,147 @@ func Test_All(t *testing.T) {
 	})
 }
 
+func Test_Each(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		for _, name := range []string{"Mark", "Joe", "Jane"} {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString(name)}))
+		}
+		r.NoError(tx.Create(nil, &CallbacksUser{}))
+
+		var names []string
+		err := tx.Where("name <> ?", "Joe").Order("name").Each(nil, &User{}, func(m interface{}) error {
+			names = append(names, m.(*User).Name.String)
+			return nil
+		})
+		r.NoError(err)
+		r.Equal([]string{"Jane", "Mark"}, names)
+
+		err = tx.Q().Each(nil, &CallbacksUser{}, func(m interface{}) error {
+			r.Equal("AfterFind", m.(*CallbacksUser).AfterF)
+			return nil
+		})
+		r.NoError(err)
+
+		stop := errors.New("stop")
+		calls := 0
+		err = tx.Q().Each(nil, &CallbacksUser{}, func(interface{}) error {
+			calls++
+			return stop
+		})
+		r.ErrorIs(err, stop)
+		r.Equal(1, calls)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = tx.Q().EachContext(ctx, &CallbacksUser{}, func(interface{}) error {
+			return nil
+		})
+		r.ErrorIs(err, context.Canceled)
+
+		r.Error(tx.Q().Each(nil, &[]CallbacksUser{}, func(interface{}) error { return nil }))
+
+		// eager loading would query the connection the records are read from
+		err = tx.Q().Eager("Books").Each(nil, &User{}, func(interface{}) error { return nil })
+		r.Error(err)
+		r.Contains(err.Error(), "FindInBatches")
+	})
+}
+
+func Test_Each_Transaction(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	switch PDB.Dialect.Name() {
+	case namePostgreSQL, nameCockroach, nameMySQL, nameMariaDB:
+	default:
+		t.Skip("only the drivers with a single busy connection per transaction are relevant")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		for _, name := range []string{"Mark", "Joe", "Jane"} {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString(name)}))
+		}
+
+		// the connection of the transaction is released when the iteration
+		// stops early
+		stop := errors.New("stop")
+		err := tx.Q().Each(nil, &User{}, func(interface{}) error { return stop })
+		r.ErrorIs(err, stop)
+		n, err := tx.Count(nil, &User{})
+		r.NoError(err)
+		r.Equal(3, n)
+
+		var names []string
+		err = tx.Q().Order("name").Each(nil, &User{}, func(m interface{}) error {
+			names = append(names, m.(*User).Name.String)
+			return nil
+		})
+		r.NoError(err)
+		r.Equal([]string{"Jane", "Joe", "Mark"}, names)
+		r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Larry")}))
+	})
+}
+
+func Test_FindInBatches(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		users := make([]User, 7)
+		for i := range users {
+			users[i].Name = nulls.NewString(fmt.Sprintf("user %d", i))
+		}
+		r.NoError(tx.CreateMany(nil, &users))
+		r.NoError(tx.Create(nil, &User{Name: nulls.NewString("other")}))
+
+		var sizes []int
+		var ids []int
+		err := tx.Where("name LIKE ?", "user %").FindInBatches(nil, 3, &Users{}, func(m interface{}) error {
+			batch := *m.(*Users)
+			sizes = append(sizes, len(batch))
+			for _, u := range batch {
+				ids = append(ids, u.ID)
+			}
+			return nil
+		})
+		r.NoError(err)
+		r.Equal([]int{3, 3, 1}, sizes)
+		r.Len(ids, 7)
+		for i := 1; i < len(ids); i++ {
+			r.Less(ids[i-1], ids[i])
+		}
+
+		sizes = nil
+		err = tx.Where("name LIKE ?", "user %").FindInBatches(nil, 7, &Users{}, func(m interface{}) error {
+			sizes = append(sizes, len(*m.(*Users)))
+			return nil
+		})
+		r.NoError(err)
+		r.Equal([]int{7}, sizes)
+
+		// fn may truncate the batch it is given
+		sizes = nil
+		err = tx.Where("name LIKE ?", "user %").FindInBatches(nil, 3, &Users{}, func(m interface{}) error {
+			batch := m.(*Users)
+			sizes = append(sizes, len(*batch))
+			*batch = (*batch)[:0]
+			return nil
+		})
+		r.NoError(err)
+		r.Equal([]int{3, 3, 1}, sizes)
+
+		r.Error(tx.Q().FindInBatches(nil, 0, &Users{}, func(interface{}) error { return nil }))
+	})
+}
+
 func Test_All_Eager_Slice_With_All(t *testing.T) {
 	if PDB == nil {
 		t.Skip("skipping integration tests")
@@ -1,12 +1,15 @@
 package pop
 
 import (
+	"context"
 	"fmt"
 	stdlog "log"
+	"log/slog"
 	"os"
+	"time"
 
-	"github.com/fatih/color"
 	"github.com/Accefy/pop/logging"
+	"github.com/fatih/color"
 	"github.com/gofrs/uuid"
 )
 
@@ -18,21 +21,49 @@ var Color = true
 
 // SetLogger overrides the default logger.
 func SetLogger(logger func(level logging.Level, requestID *uuid.UUID, s string, args ...interface{})) {
+	if slogger != nil {
+		slogger = nil
+		txlog = defaultTxLog
+	}
 	log = logger
 }
 
 // SetLogger overrides the default logger.
 func SetTxLogger(logger func(level logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{})) {
+	slogger = nil
 	txlog = logger
 }
 
+// SetSlogHandler switches logging to structured logging through the given
+// log/slog handler. Instead of a formatted line, SQL statements are emitted
+// with separate attributes for the statement, its arguments and duration,
+// the connection, transaction and request IDs, the table name and the
+// connection pool stats. Passing nil restores the default logger.
+//
+// SQL statements are logged with level logging.SlogLevelSQL. As with the
+// default logger, SQL and debug logs are only emitted when Debug is set.
+func SetSlogHandler(h slog.Handler) {
+	if h == nil {
+		slogger = nil
+		txlog = defaultTxLog
+		return
+	}
+	slogger = slog.New(h)
+	txlog = slogTxLog
+}
+
 var defaultStdLogger = stdlog.New(os.Stderr, "[POP] ", stdlog.LstdFlags)
 
+// slogger is set when logging through a log/slog handler.
+var slogger *slog.Logger
+
 var log = func(lvl logging.Level, requestID *uuid.UUID, s string, args ...interface{}) {
 	txlog(lvl, requestID, nil, s, args...)
 }
 
-var txlog = func(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{}) {
+var txlog = defaultTxLog
+
+func defaultTxLog(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{}) {
 	if !Debug && lvl <= logging.Debug {
 		return
 	}
@@ -86,6 +117,97 @@ var txlog = func(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s st
 	defaultStdLogger.Println(s)
 }
 
+// slogTxLog is the txlog implementation used in slog mode. SQL statements
+// executed through logSQL are not passed here, they are emitted by logSQL
+// once their duration is known.
+func slogTxLog(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{}) {
+	if !Debug && lvl <= logging.Debug {
+		return
+	}
+	msg := s
+	attrs := slogAttrs(requestID, anon)
+	if lvl == logging.SQL {
+		if len(args) > 0 {
+			attrs = append(attrs, slog.Any("args", args))
+		}
+	} else {
+		msg = fmt.Sprintf(s, args...)
+	}
+	slogger.LogAttrs(context.Background(), lvl.SlogLevel(), msg, attrs...)
+}
+
+// logSQL logs the given statement and runs fn, which is expected to execute
+// it. anon is the *Connection, *Tx or store the statement runs on, and table
+// the name of the table it is about, if any. Statements run on a *Connection
+// are also reported if they are slow, see `Connection.logSlowQuery`.
+func logSQL(requestID *uuid.UUID, anon interface{}, table string, stmt string, args []interface{}, fn func() error) error {
+	elapsed, err := runSQL(requestID, anon, table, stmt, args, fn)
+	if c, ok := anon.(*Connection); ok {
+		c.logSlowQuery(requestID, table, stmt, args, elapsed, err)
+	}
+	return err
+}
+
+// runSQL is logSQL without the slow query report. It returns how long fn
+// took, for the callers reporting slow queries themselves.
+func runSQL(requestID *uuid.UUID, anon interface{}, table string, stmt string, args []interface{}, fn func() error) (time.Duration, error) {
+	if slogger == nil {
+		txlog(logging.SQL, requestID, anon, stmt, args...)
+	}
+
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	if slogger != nil && Debug {
+		attrs := append(slogAttrs(requestID, anon),
+			slog.String("sql", stmt),
+			slog.Any("args", args),
+			slog.Duration("duration", elapsed),
+		)
+		if table != "" {
+			attrs = append(attrs, slog.String("table", table))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slogger.LogAttrs(context.Background(), logging.SlogLevelSQL, stmt, attrs...)
+	}
+	return elapsed, err
+}
+
+// logTableName returns the table name of m, or an empty string when m is
+// not backed by a model, e.g. for raw queries.
+func logTableName(m *Model) string {
+	if m == nil || m.Value == nil {
+		return ""
+	}
+	return m.TableName()
+}
+
+// slogAttrs returns the connection, transaction and request IDs as well as
+// the connection pool stats as log/slog attributes.
+func slogAttrs(requestID *uuid.UUID, anon interface{}) []slog.Attr {
+	var attrs []slog.Attr
+	if requestID != nil {
+		attrs = append(attrs, slog.String("request_id", requestID.String()))
+	}
+
+	switch typed := anon.(type) {
+	case *Connection:
+		attrs = append(attrs, slog.String("conn_id", typed.ID))
+		if typed.TX != nil {
+			attrs = append(attrs, slog.Int("tx_id", typed.TX.ID))
+		}
+		attrs = append(attrs, statsAttrs(typed.Store)...)
+	case *Tx:
+		attrs = append(attrs, slog.Int("tx_id", typed.ID))
+	case store:
+		attrs = append(attrs, statsAttrs(typed)...)
+	}
+	return attrs
+}
+
 // printStats returns a string represent connection pool information from
 // the given store.
 func printStats(s *store) string {
@@ -96,3 +218,18 @@ func printStats(s *store) string {
 
 	return ""
 }
+
+// statsAttrs returns connection pool information from the given store as
+// a log/slog group attribute.
+func statsAttrs(s store) []slog.Attr {
+	if db, ok := s.(*dB); ok {
+		s := db.Stats()
+		return []slog.Attr{slog.Group("pool",
+			slog.Int("maxconn", s.MaxOpenConnections),
+			slog.Int("openconn", s.OpenConnections),
+			slog.Int("in_use", s.InUse),
+			slog.Int("idle", s.Idle),
+		)}
+	}
+	return nil
+}
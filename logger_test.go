@@ -0,0 +1,115 @@
+package pop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func withSlogHandler(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	oldDebug := Debug
+	t.Cleanup(func() {
+		Debug = oldDebug
+		SetSlogHandler(nil)
+	})
+
+	Debug = true
+	bb := &bytes.Buffer{}
+	SetSlogHandler(slog.NewJSONHandler(bb, &slog.HandlerOptions{Level: logging.SlogLevelSQL}))
+	return bb
+}
+
+func Test_SetSlogHandler_SQL(t *testing.T) {
+	r := require.New(t)
+	bb := withSlogHandler(t)
+
+	id := uuid.Must(uuid.NewV4())
+	c := &Connection{ID: "conn-1", TX: &Tx{ID: 42}}
+	err := logSQL(&id, c, "users", "SELECT * FROM users WHERE id = ?", []interface{}{1}, func() error {
+		return errors.New("boom")
+	})
+	r.EqualError(err, "boom")
+
+	entry := map[string]interface{}{}
+	r.NoError(json.Unmarshal(bb.Bytes(), &entry))
+	r.Equal("SELECT * FROM users WHERE id = ?", entry["sql"])
+	r.Equal([]interface{}{float64(1)}, entry["args"])
+	r.Equal("users", entry["table"])
+	r.Equal("conn-1", entry["conn_id"])
+	r.Equal(float64(42), entry["tx_id"])
+	r.Equal(id.String(), entry["request_id"])
+	r.Equal("boom", entry["error"])
+	r.Contains(entry, "duration")
+	r.Equal(logging.SlogLevelSQL.String(), entry["level"])
+}
+
+func Test_SetSlogHandler_Lifecycle(t *testing.T) {
+	r := require.New(t)
+	bb := withSlogHandler(t)
+
+	c := &Connection{ID: "conn-1", TX: &Tx{ID: 42}}
+	txlog(logging.SQL, nil, c, "BEGIN Transaction ---")
+	log(logging.Info, nil, "created database %s", "pop_test")
+
+	dec := json.NewDecoder(bb)
+	entry := map[string]interface{}{}
+	r.NoError(dec.Decode(&entry))
+	r.Equal("BEGIN Transaction ---", entry["msg"])
+	r.Equal(float64(42), entry["tx_id"])
+	r.NotContains(entry, "request_id")
+
+	entry = map[string]interface{}{}
+	r.NoError(dec.Decode(&entry))
+	r.Equal("created database pop_test", entry["msg"])
+	r.Equal("INFO", entry["level"])
+}
+
+func Test_SetSlogHandler_Debug(t *testing.T) {
+	r := require.New(t)
+	bb := withSlogHandler(t)
+	Debug = false
+
+	r.NoError(logSQL(nil, nil, "", "SELECT 1", nil, func() error { return nil }))
+	log(logging.Debug, nil, "hidden")
+	r.Empty(bb.String())
+}
+
+func Test_SetLogger_AfterSetSlogHandler(t *testing.T) {
+	r := require.New(t)
+	withSlogHandler(t)
+
+	oldLog := log
+	defer func() { log = oldLog }()
+	bb := &bytes.Buffer{}
+	defaultStdLogger.SetOutput(bb)
+	defer defaultStdLogger.SetOutput(os.Stderr)
+
+	var logs []string
+	SetLogger(func(lvl logging.Level, requestID *uuid.UUID, s string, args ...interface{}) {
+		logs = append(logs, s)
+	})
+
+	c := &Connection{ID: "conn-1", TX: &Tx{ID: 42}}
+	r.NotPanics(func() {
+		r.NoError(logSQL(nil, c, "users", "SELECT * FROM users", nil, func() error { return nil }))
+		txlog(logging.SQL, nil, c, "BEGIN Transaction ---")
+		log(logging.Info, nil, "created database %s", "pop_test")
+	})
+	r.Contains(bb.String(), "SELECT * FROM users")
+	r.Equal([]string{"created database %s"}, logs)
+
+	if PDB != nil {
+		r.NotPanics(func() {
+			r.NoError(PDB.RawQuery("SELECT 1").Exec(nil))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package logging
+
+import "log/slog"
+
+// SlogLevelSQL is the log/slog level used for SQL statements. It is lower
+// than slog.LevelDebug, the same way SQL is lower than Debug.
+const SlogLevelSQL = slog.LevelDebug - 4
+
+// SlogLevel returns the log/slog level corresponding to l.
+func (l Level) SlogLevel() slog.Level {
+	switch l {
+	case SQL:
+		return SlogLevelSQL
+	case Debug:
+		return slog.LevelDebug
+	case Info:
+		return slog.LevelInfo
+	case Warn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
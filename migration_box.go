@@ -1,8 +1,8 @@
 package pop
 
 import (
+	"bytes"
 	"fmt"
-	"io"
 	"io/fs"
 	"strings"
 
@@ -11,7 +11,8 @@ import (
 
 // MigrationBox is a wrapper around fs.FS and Migrator.
 // This will allow you to run migrations from a fs.FS
-// inside of a compiled binary.
+// inside of a compiled binary, along the migrations
+// added with RegisterMigration.
 type MigrationBox struct {
 	Migrator
 	FS fs.FS
@@ -24,24 +25,17 @@ func NewMigrationBox(fsys fs.FS, c *Connection) (MigrationBox, error) {
 		FS:       fsys,
 	}
 
-	runner := func(r io.Reader) func(mf Migration, tx *Connection) error {
-		return func(mf Migration, tx *Connection) error {
-			content, err := MigrationContent(mf, tx, r, true)
+	content := func(b []byte) func(mf Migration, tx *Connection) (string, error) {
+		return func(mf Migration, tx *Connection) (string, error) {
+			content, err := MigrationContent(mf, tx, bytes.NewReader(b), true)
 			if err != nil {
-				return fmt.Errorf("error processing %s: %w", mf.Path, err)
+				return "", fmt.Errorf("error processing %s: %w", mf.Path, err)
 			}
-			if content == "" {
-				return nil
-			}
-			err = tx.RawQuery(content).Exec(nil)
-			if err != nil {
-				return fmt.Errorf("error executing %s, sql: %s: %w", mf.Path, content, err)
-			}
-			return nil
+			return content, nil
 		}
 	}
 
-	err := fm.findMigrations(runner)
+	err := fm.findMigrations(content)
 	if err != nil {
 		return fm, err
 	}
@@ -49,7 +43,7 @@ func NewMigrationBox(fsys fs.FS, c *Connection) (MigrationBox, error) {
 	return fm, nil
 }
 
-func (fm *MigrationBox) findMigrations(runner func(r io.Reader) func(mf Migration, tx *Connection) error) error {
+func (fm *MigrationBox) findMigrations(content func(b []byte) func(mf Migration, tx *Connection) (string, error)) error {
 	return fs.WalkDir(fm.FS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -77,19 +71,23 @@ func (fm *MigrationBox) findMigrations(runner func(r io.Reader) func(mf Migratio
 			return nil
 		}
 
-		f, err := fm.FS.Open(path)
+		b, err := fs.ReadFile(fm.FS, path)
 		if err != nil {
 			return err
 		}
+		_, noTransaction := cutNoTransactionMarker(b)
 
 		mf := Migration{
-			Path:      path,
-			Version:   match.Version,
-			Name:      match.Name,
-			DBType:    match.DBType,
-			Direction: match.Direction,
-			Type:      match.Type,
-			Runner:    runner(f),
+			Path:          path,
+			Version:       match.Version,
+			Name:          match.Name,
+			DBType:        match.DBType,
+			Direction:     match.Direction,
+			Type:          match.Type,
+			Checksum:      migrationChecksum(b),
+			NoTransaction: noTransaction,
+			Content:       content(b),
+			Runner:        runMigrationContent,
 		}
 		switch mf.Direction {
 		case "up":
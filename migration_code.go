@@ -0,0 +1,96 @@
+package pop
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+)
+
+var codeMigrationVersionRx = regexp.MustCompile(`^\d+$`)
+
+// RegisterMigration adds a migration written in Go to m. It is run along
+// the other migrations of m, such as the migration files of a FileMigrator
+// or a MigrationBox, and recorded in the same migration table.
+//
+//	fm, err := pop.NewFileMigrator("./migrations", c)
+//	if err != nil {
+//		return err
+//	}
+//	err = fm.RegisterMigration("20240101120000", "backfill_slugs", backfillSlugs, nil)
+//
+// The version is the timestamp ordering the migration among the others.
+// The down function can be nil for the migrations which cannot be rolled
+// back, which are marked as irreversible: Migrator.Down fails once it
+// reaches them. RegisterMigration returns an error if the version is
+// invalid or already used by another migration of m, or if up is nil.
+func (m *Migrator) RegisterMigration(version, name string, up, down func(*Connection) error) error {
+	if !codeMigrationVersionRx.MatchString(version) {
+		return fmt.Errorf("invalid migration version %q", version)
+	}
+	if name == "" {
+		return fmt.Errorf("migration %s has no name", version)
+	}
+	if up == nil {
+		return fmt.Errorf("migration %s_%s has no up function", version, name)
+	}
+
+	path := fmt.Sprintf("%s_%s", version, name)
+	if _, file, line, ok := runtime.Caller(1); ok {
+		path = fmt.Sprintf("%s:%d", file, line)
+	}
+	for _, mf := range append(m.UpMigrations.Migrations, m.DownMigrations.Migrations...) {
+		if mf.Version == version {
+			return fmt.Errorf("migration version %s is used by both %s and %s", version, path, mf.Path)
+		}
+	}
+
+	mf := Migration{
+		Path:         path,
+		Version:      version,
+		Name:         name,
+		Direction:    "up",
+		Type:         "go",
+		DBType:       "all",
+		Irreversible: down == nil,
+		Runner:       codeMigrationRunner(up),
+	}
+	m.UpMigrations.Migrations = insertMigration(m.UpMigrations.Migrations, mf)
+	if down != nil {
+		mf.Direction = "down"
+		mf.Runner = codeMigrationRunner(down)
+		m.DownMigrations.Migrations = insertMigration(m.DownMigrations.Migrations, mf)
+	}
+	return nil
+}
+
+func codeMigrationRunner(fn func(*Connection) error) func(Migration, *Connection) error {
+	return func(mf Migration, tx *Connection) error {
+		if err := fn(tx); err != nil {
+			return fmt.Errorf("error running %s: %w", mf.Path, err)
+		}
+		return nil
+	}
+}
+
+// CodeMigrator is a migrator for migrations written in Go only, added
+// with RegisterMigration.
+type CodeMigrator struct {
+	Migrator
+}
+
+// NewCodeMigrator for a Connection, without any migration.
+func NewCodeMigrator(c *Connection) (CodeMigrator, error) {
+	return CodeMigrator{
+		Migrator: NewMigrator(c),
+	}, nil
+}
+
+// insertMigration inserts mf in mfs, before the first migration with a
+// later version, keeping the order of the others.
+func insertMigration(mfs Migrations, mf Migration) Migrations {
+	i := 0
+	for i < len(mfs) && mfs[i].Version < mf.Version {
+		i++
+	}
+	return append(mfs[:i], append(Migrations{mf}, mfs[i:]...)...)
+}
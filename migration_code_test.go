@@ -0,0 +1,163 @@
+package pop
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Migrator_RegisterMigration(t *testing.T) {
+	r := require.New(t)
+	noop := func(*Connection) error { return nil }
+
+	cm, err := NewCodeMigrator(PDB)
+	r.NoError(err)
+	r.NoError(cm.RegisterMigration("20240101000000", "first", noop, noop))
+	r.NoError(cm.RegisterMigration("20230101000000", "irreversible", noop, nil))
+	r.Len(cm.UpMigrations.Migrations, 2)
+	r.Len(cm.DownMigrations.Migrations, 1)
+	r.Equal("irreversible", cm.UpMigrations.Migrations[0].Name)
+	r.True(cm.UpMigrations.Migrations[0].Irreversible)
+	r.Equal("first", cm.UpMigrations.Migrations[1].Name)
+	r.False(cm.UpMigrations.Migrations[1].Irreversible)
+	r.Equal("go", cm.UpMigrations.Migrations[0].Type)
+	r.Equal("all", cm.UpMigrations.Migrations[0].DBType)
+	r.Contains(cm.UpMigrations.Migrations[0].Path, "migration_code_test.go")
+
+	r.Error(cm.RegisterMigration("20240101000000", "again", noop, nil))
+	r.Error(cm.RegisterMigration("2024-01-01", "dashed", noop, nil))
+	r.Error(cm.RegisterMigration("20240102000000", "", noop, nil))
+	r.Error(cm.RegisterMigration("20240102000000", "no_up", nil, noop))
+	r.Len(cm.UpMigrations.Migrations, 2)
+
+	boom := errors.New("boom")
+	r.NoError(cm.RegisterMigration("20240103000000", "failing", func(*Connection) error { return boom }, nil))
+	r.True(errors.Is(cm.UpMigrations.Migrations[2].Run(PDB), boom))
+
+	// the migrations are scoped to the migrator they are registered for
+	other, err := NewCodeMigrator(PDB)
+	r.NoError(err)
+	r.Empty(other.UpMigrations.Migrations)
+	r.NoError(other.RegisterMigration("20240101000000", "first", noop, noop))
+}
+
+func Test_CodeMigrator(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "code_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS code_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_code_widgets.up.sql", "CREATE TABLE code_widgets (id INT);")
+		writeMigration(t, dir, "20240101000000_code_widgets.down.sql", "DROP TABLE code_widgets;")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.NoError(fm.RegisterMigration("20240102000000", "seed_widgets", func(tx *Connection) error {
+			return tx.RawQuery("INSERT INTO code_widgets (id) VALUES (?), (?)", 1, 2).Exec(nil)
+		}, func(tx *Connection) error {
+			return tx.RawQuery("DELETE FROM code_widgets").Exec(nil)
+		}))
+		r.Len(fm.UpMigrations.Migrations, 2)
+		r.Equal("code_widgets", fm.UpMigrations.Migrations[0].Name)
+		r.Equal("seed_widgets", fm.UpMigrations.Migrations[1].Name)
+
+		// a version cannot be used by both a file and a function
+		r.Error(fm.RegisterMigration("20240101000000", "seed", func(*Connection) error { return nil }, nil))
+
+		r.NoError(fm.Up())
+		n, err := PDB.Count(nil, "code_widgets")
+		r.NoError(err)
+		r.Equal(2, n)
+
+		out := &bytes.Buffer{}
+		r.NoError(fm.Status(out))
+		r.Regexp(`20240102000000\s+seed_widgets\s+Applied`, out.String())
+
+		drift, err := fm.Verify()
+		r.NoError(err)
+		r.False(drift.HasAny())
+
+		r.NoError(fm.Down(1))
+		n, err = PDB.Count(nil, "code_widgets")
+		r.NoError(err)
+		r.Equal(0, n)
+		r.NoError(fm.Down(1))
+	})
+}
+
+func Test_CodeMigrator_Irreversible(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "irreversible_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS irreversible_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_irreversible_widgets.up.sql", "CREATE TABLE irreversible_widgets (id INT);")
+		writeMigration(t, dir, "20240101000000_irreversible_widgets.down.sql", "DROP TABLE irreversible_widgets;")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.NoError(fm.RegisterMigration("20240102000000", "seed_widgets", func(tx *Connection) error {
+			return tx.RawQuery("INSERT INTO irreversible_widgets (id) VALUES (?)", 1).Exec(nil)
+		}, nil))
+		r.NoError(fm.Up())
+
+		// the latest migration cannot be rolled back, so the earlier one
+		// is not either
+		r.Error(fm.Down(1))
+		applied, err := fm.Applied()
+		r.NoError(err)
+		r.Len(applied, 2)
+		n, err := PDB.Count(nil, "irreversible_widgets")
+		r.NoError(err)
+		r.Equal(1, n)
+	})
+}
+
+func Test_Migrator_Down_MissingMigration(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "missing_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS missing_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_missing_widgets.up.sql", "CREATE TABLE missing_widgets (id INT);")
+		writeMigration(t, dir, "20240101000000_missing_widgets.down.sql", "DROP TABLE missing_widgets;")
+		writeMigration(t, dir, "20240102000000_legacy.up.sql", "")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.NoError(fm.Up())
+
+		// the files of a legacy migration are removed after it was applied
+		r.NoError(os.Remove(filepath.Join(dir, "20240102000000_legacy.up.sql")))
+		fm, err = NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+
+		r.NoError(fm.Reset())
+		applied, err := fm.Applied()
+		r.NoError(err)
+		r.Len(applied, 2)
+		n, err := PDB.Count(nil, "missing_widgets")
+		r.NoError(err)
+		r.Equal(0, n)
+	})
+}
@@ -16,6 +16,10 @@ func MigrationContent(mf Migration, c *Connection, r io.Reader, usingTemplate bo
 	if err != nil {
 		return "", nil
 	}
+	if mf.Type == "fizz" {
+		// the marker is not valid Fizz
+		b, _ = cutNoTransactionMarker(b)
+	}
 
 	content := ""
 	if usingTemplate {
@@ -39,3 +43,18 @@ func MigrationContent(mf Migration, c *Connection, r io.Reader, usingTemplate bo
 
 	return content, nil
 }
+
+// noTransactionMarker is the first line of the migrations to run outside of
+// a transaction, for the statements which cannot run in one, such as
+// PostgreSQL CREATE INDEX CONCURRENTLY.
+const noTransactionMarker = "-- pop:no-transaction"
+
+// cutNoTransactionMarker returns content without its first line if it is
+// the no transaction marker, and whether it was found.
+func cutNoTransactionMarker(content []byte) ([]byte, bool) {
+	line, rest, _ := bytes.Cut(content, []byte("\n"))
+	if string(bytes.TrimSpace(line)) != noTransactionMarker {
+		return content, false
+	}
+	return rest, true
+}
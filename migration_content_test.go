@@ -0,0 +1,30 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cutNoTransactionMarker(t *testing.T) {
+	r := require.New(t)
+
+	content, ok := cutNoTransactionMarker([]byte("-- pop:no-transaction\nCREATE INDEX CONCURRENTLY a_idx ON a (b);"))
+	r.True(ok)
+	r.Equal("CREATE INDEX CONCURRENTLY a_idx ON a (b);", string(content))
+
+	content, ok = cutNoTransactionMarker([]byte("-- pop:no-transaction\r\nsql(\"x\")"))
+	r.True(ok)
+	r.Equal("sql(\"x\")", string(content))
+
+	for _, s := range []string{
+		"",
+		"CREATE INDEX a_idx ON a (b);",
+		"CREATE INDEX a_idx ON a (b);\n-- pop:no-transaction",
+		"-- pop:no-transactions\n",
+	} {
+		content, ok = cutNoTransactionMarker([]byte(s))
+		r.False(ok, s)
+		r.Equal(s, string(content))
+	}
+}
@@ -0,0 +1,256 @@
+package pop
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gobuffalo/fizz"
+	"github.com/gobuffalo/nulls"
+)
+
+// ErrMigrationsUnverifiable is returned by Migrator.Verify when the
+// migration table cannot be read, e.g. because it does not exist yet.
+var ErrMigrationsUnverifiable = errors.New("applied migrations cannot be verified")
+
+// schemaMigrationsDetailColumns are the columns of the migration table
+// besides the version. They are nullable so that the tables created before
+// they existed can be upgraded in place.
+var schemaMigrationsDetailColumns = []fizz.Column{
+	{Name: "name", ColType: "string", Options: map[string]interface{}{"size": 255, "null": true}},
+	{Name: "checksum", ColType: "string", Options: map[string]interface{}{"size": 64, "null": true}},
+	{Name: "applied_at", ColType: "timestamp", Options: map[string]interface{}{"null": true}},
+	{Name: "duration_ms", ColType: "integer", Options: map[string]interface{}{"null": true}},
+}
+
+// AppliedMigration is a migration recorded in the migration table.
+type AppliedMigration struct {
+	Version string `db:"version"`
+	// Name of the migration, if recorded.
+	Name nulls.String `db:"name"`
+	// Checksum of the migration when it was applied, if recorded.
+	Checksum nulls.String `db:"checksum"`
+	// AppliedAt is when the migration was applied, if recorded.
+	AppliedAt nulls.Time `db:"applied_at"`
+	// DurationMS is how long the migration took to run, in milliseconds,
+	// if it was run.
+	DurationMS nulls.Int64 `db:"duration_ms"`
+}
+
+// MigrationDrift lists the differences between the up migrations of a
+// Migrator and the migrations applied to the database, as found by
+// Migrator.Verify.
+type MigrationDrift struct {
+	// Modified are the applied migrations whose content changed since.
+	Modified Migrations
+	// Missing are the migrations which have not been applied although a
+	// later one has, e.g. because they were merged out of order.
+	Missing Migrations
+	// Unknown are the migrations applied to the database which have no
+	// up migration.
+	Unknown []AppliedMigration
+	// Unverified are the applied migrations whose checksum was not
+	// recorded, e.g. because they were applied before the migration table
+	// was upgraded. They are not counted as drift, and their checksum is
+	// recorded by the next Up.
+	Unverified []AppliedMigration
+}
+
+// HasAny returns true if the migrations have drifted from the database.
+func (d MigrationDrift) HasAny() bool {
+	return len(d.Modified) > 0 || len(d.Missing) > 0 || len(d.Unknown) > 0
+}
+
+// migrationChecksum returns the checksum of the content of a migration
+// file, before it is templated or translated for the dialect.
+func migrationChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Applied returns the migrations recorded in the migration table, ordered
+// by version. The details missing from a migration table which was not
+// upgraded yet are returned as null. The migration table is only read.
+func (m Migrator) Applied() ([]AppliedMigration, error) {
+	c := m.Connection
+	if err := c.Open(); err != nil {
+		return nil, fmt.Errorf("could not open connection: %w", err)
+	}
+	existing, err := schemaMigrationsColumns(c)
+	if err != nil {
+		return nil, err
+	}
+	cols := []string{"version"}
+	for _, col := range schemaMigrationsDetailColumns {
+		if existing[col.Name] {
+			cols = append(cols, col.Name)
+		} else {
+			cols = append(cols, "null as "+col.Name)
+		}
+	}
+	var applied []AppliedMigration
+	stmt := fmt.Sprintf("select %s from %s order by version", strings.Join(cols, ", "), c.MigrationTableName())
+	if err := c.Store.Select(&applied, stmt); err != nil {
+		return nil, fmt.Errorf("could not list applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// Verify compares the up migrations to the ones applied to the database,
+// and reports the applied migrations which were modified since, the ones
+// skipped, and the ones without an up migration. It does not write to the
+// database, and returns ErrMigrationsUnverifiable if the migration table
+// cannot be read.
+//
+// The applied migrations whose checksum was not recorded are reported as
+// unverified.
+func (m Migrator) Verify() (MigrationDrift, error) {
+	var drift MigrationDrift
+	applied, err := m.Applied()
+	if err != nil {
+		return drift, fmt.Errorf("%w: %w", ErrMigrationsUnverifiable, err)
+	}
+
+	migrations := m.appliedUpMigrations()
+	latest := ""
+	for _, am := range applied {
+		mi, ok := migrations[am.Version]
+		switch {
+		case !ok:
+			drift.Unknown = append(drift.Unknown, am)
+		case mi.Checksum == "":
+		case !am.Checksum.Valid:
+			drift.Unverified = append(drift.Unverified, am)
+		case am.Checksum.String != mi.Checksum:
+			drift.Modified = append(drift.Modified, mi)
+		}
+		delete(migrations, am.Version)
+		if am.Version > latest {
+			latest = am.Version
+		}
+	}
+	for version, mi := range migrations {
+		if version < latest {
+			drift.Missing = append(drift.Missing, mi)
+		}
+	}
+	sort.Sort(UpMigrations{drift.Missing})
+	return drift, nil
+}
+
+// appliedUpMigrations returns the up migrations compatible with the dialect,
+// by version. For a version with several migrations, the one applied by
+// UpTo is returned.
+func (m Migrator) appliedUpMigrations() map[string]Migration {
+	c := m.Connection
+	mfs := UpMigrations{append(Migrations{}, m.UpMigrations.Migrations...)}
+	mfs.Filter(func(mf Migration) bool {
+		return m.migrationIsCompatible(c.Dialect, mf)
+	})
+	sort.Sort(mfs)
+	migrations := make(map[string]Migration, len(mfs.Migrations))
+	for _, mi := range mfs.Migrations {
+		if _, ok := migrations[mi.Version]; !ok {
+			migrations[mi.Version] = mi
+		}
+	}
+	return migrations
+}
+
+// recordMigration inserts mi in the migration table. The duration is nil
+// for the migrations recorded without being run.
+func recordMigration(tx *Connection, mi Migration, duration *time.Duration) error {
+	var ms nulls.Int64
+	if duration != nil {
+		ms = nulls.NewInt64(duration.Milliseconds())
+	}
+	checksum := nulls.String{String: mi.Checksum, Valid: mi.Checksum != ""}
+	stmt := fmt.Sprintf("insert into %s (version, name, checksum, applied_at, duration_ms) values (?, ?, ?, ?, ?)", tx.MigrationTableName())
+	err := tx.RawQuery(stmt, mi.Version, mi.Name, checksum, time.Now(), ms).Exec(nil)
+	if err != nil {
+		return fmt.Errorf("problem inserting migration version %s: %w", mi.Version, err)
+	}
+	return nil
+}
+
+// upgradeSchemaMigrations adds the columns missing from a migration table
+// created by a previous version.
+func upgradeSchemaMigrations(c *Connection) error {
+	mtn := c.MigrationTableName()
+	existing, err := schemaMigrationsColumns(c)
+	if err != nil {
+		return err
+	}
+	for _, col := range schemaMigrationsDetailColumns {
+		if existing[col.Name] {
+			continue
+		}
+		stmt, err := c.Dialect.FizzTranslator().AddColumn(fizz.Table{Name: mtn, Columns: []fizz.Column{col}})
+		if err != nil {
+			return fmt.Errorf("could not build SQL to add column %s to %s: %w", col.Name, mtn, err)
+		}
+		if err := c.RawQuery(stmt).Exec(nil); err != nil {
+			// another process may have upgraded the table at the same time
+			if existing, cerr := schemaMigrationsColumns(c); cerr == nil && existing[col.Name] {
+				continue
+			}
+			return fmt.Errorf("could not add column %s to %s: %w", col.Name, mtn, err)
+		}
+		log(logging.Info, nil, "Migrator: added column %s to %s", col.Name, mtn)
+	}
+	return nil
+}
+
+// schemaMigrationsColumns returns the names of the columns of the migration
+// table.
+func schemaMigrationsColumns(c *Connection) (map[string]bool, error) {
+	mtn := c.MigrationTableName()
+	rows, err := c.Store.QueryxContext(c.Context(), fmt.Sprintf("select * from %s where 1 = 0", mtn))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the columns of %s: %w", mtn, err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the columns of %s: %w", mtn, err)
+	}
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[strings.ToLower(name)] = true
+	}
+	return columns, nil
+}
+
+// backfillSchemaMigrations records the name and checksum of the applied
+// migrations which have none, e.g. because they were applied before the
+// migration table was upgraded.
+func (m Migrator) backfillSchemaMigrations() error {
+	c := m.Connection
+	mtn := c.MigrationTableName()
+	var versions []string
+	stmt := fmt.Sprintf("select version from %s where checksum is null", mtn)
+	if err := c.Store.Select(&versions, stmt); err != nil {
+		return fmt.Errorf("could not list migrations without checksum: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+
+	migrations := m.appliedUpMigrations()
+	for _, version := range versions {
+		mi, ok := migrations[version]
+		if !ok || mi.Checksum == "" {
+			continue
+		}
+		err := c.RawQuery(fmt.Sprintf("update %s set name = ?, checksum = ? where version = ?", mtn), mi.Name, mi.Checksum, version).Exec(nil)
+		if err != nil {
+			return fmt.Errorf("could not record the checksum of migration version %s: %w", version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package pop
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_migrationChecksum(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", migrationChecksum(nil))
+	r.Equal(migrationChecksum([]byte("create_table(\"a\")")), migrationChecksum([]byte("create_table(\"a\")")))
+	r.NotEqual(migrationChecksum([]byte("create_table(\"a\")")), migrationChecksum([]byte("create_table(\"b\")")))
+}
+
+// withMigrationTable runs fn with the migration table of PDB set to name,
+// and drops the table afterwards. The options of PDB are copied rather
+// than modified, and restored afterwards.
+func withMigrationTable(t *testing.T, name string, fn func()) {
+	deets := PDB.Dialect.Details()
+	old := deets.Options
+	deets.Options = map[string]string{}
+	for k, v := range old {
+		deets.Options[k] = v
+	}
+	deets.Options["migration_table_name"] = name
+	defer func() {
+		deets.Options = old
+		require.NoError(t, PDB.RawQuery(fmt.Sprintf("DROP TABLE IF EXISTS %s", name)).Exec(nil))
+	}()
+	fn()
+}
+
+func writeMigration(t *testing.T, dir, name, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func Test_Migrator_Verify(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "drift_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS drift_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_widgets.up.sql", "CREATE TABLE drift_widgets (id INT);")
+		writeMigration(t, dir, "20240101000000_widgets.down.sql", "DROP TABLE drift_widgets;")
+		writeMigration(t, dir, "20240102000000_noop.up.sql", "")
+
+		migrator := func() FileMigrator {
+			fm, err := NewFileMigrator(dir, PDB)
+			r.NoError(err)
+			fm.SchemaPath = ""
+			return fm
+		}
+
+		fm := migrator()
+		r.NoError(fm.Up())
+
+		applied, err := fm.Applied()
+		r.NoError(err)
+		r.Len(applied, 2)
+		r.Equal("20240101000000", applied[0].Version)
+		r.Equal("widgets", applied[0].Name.String)
+		r.Equal(fm.UpMigrations.Migrations[0].Checksum, applied[0].Checksum.String)
+		r.True(applied[0].AppliedAt.Valid)
+		r.True(applied[0].DurationMS.Valid)
+
+		drift, err := fm.Verify()
+		r.NoError(err)
+		r.False(drift.HasAny())
+
+		// a migration is edited after being applied
+		writeMigration(t, dir, "20240101000000_widgets.up.sql", "CREATE TABLE drift_widgets (id BIGINT);")
+		// a migration is merged after a later one was applied
+		writeMigration(t, dir, "20240101120000_gadgets.up.sql", "")
+		// a migration file is deleted
+		r.NoError(os.Remove(filepath.Join(dir, "20240102000000_noop.up.sql")))
+
+		drift, err = migrator().Verify()
+		r.NoError(err)
+		r.True(drift.HasAny())
+		r.Len(drift.Modified, 1)
+		r.Equal("20240101000000", drift.Modified[0].Version)
+		r.Len(drift.Missing, 1)
+		r.Equal("20240101120000", drift.Missing[0].Version)
+		r.Len(drift.Unknown, 1)
+		r.Equal("20240102000000", drift.Unknown[0].Version)
+		r.Equal("noop", drift.Unknown[0].Name.String)
+	})
+}
+
+func Test_Migrator_Verify_MissingTable(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "missing_schema_migration", func() {
+		fm, err := NewFileMigrator(t.TempDir(), PDB)
+		r.NoError(err)
+
+		_, err = fm.Verify()
+		r.True(errors.Is(err, ErrMigrationsUnverifiable), "%v", err)
+		_, err = schemaMigrationsColumns(PDB)
+		r.Error(err)
+	})
+}
+
+func Test_CreateSchemaMigrations_Upgrade(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "old_schema_migration", func() {
+		r.NoError(PDB.RawQuery("CREATE TABLE old_schema_migration (version VARCHAR(14) NOT NULL)").Exec(nil))
+		r.NoError(PDB.RawQuery("INSERT INTO old_schema_migration (version) VALUES (?), (?)", "20240101000000", "20240102000000").Exec(nil))
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_widgets.up.sql", "CREATE TABLE drift_widgets (id INT);")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+
+		// verifying reads the table as it is
+		drift, err := fm.Verify()
+		r.NoError(err)
+		r.Len(drift.Unknown, 1)
+		r.Len(drift.Unverified, 1)
+		r.Equal("20240101000000", drift.Unverified[0].Version)
+		columns, err := schemaMigrationsColumns(PDB)
+		r.NoError(err)
+		r.Len(columns, 1)
+
+		r.NoError(fm.Up())
+		columns, err = schemaMigrationsColumns(PDB)
+		r.NoError(err)
+		for _, col := range schemaMigrationsDetailColumns {
+			r.True(columns[col.Name], col.Name)
+		}
+
+		// the migrations applied before the upgrade are trusted
+		applied, err := fm.Applied()
+		r.NoError(err)
+		r.Len(applied, 2)
+		r.Equal("widgets", applied[0].Name.String)
+		r.Equal(fm.UpMigrations.Migrations[0].Checksum, applied[0].Checksum.String)
+		r.False(applied[0].AppliedAt.Valid)
+		r.False(applied[1].Checksum.Valid)
+
+		drift, err = fm.Verify()
+		r.NoError(err)
+		r.Empty(drift.Unverified)
+
+		// upgrading is idempotent
+		r.NoError(fm.CreateSchemaMigrations())
+	})
+}
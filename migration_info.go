@@ -16,6 +16,17 @@ type Migration struct {
 	Type string
 	// DB type (all|postgres|mysql...)
 	DBType string
+	// Checksum of the content of the migration, recorded when it is applied
+	Checksum string
+	// NoTransaction runs the migration outside of a transaction, if its
+	// first line is "-- pop:no-transaction"
+	NoTransaction bool
+	// Irreversible is set on the migrations written in Go without a down
+	// function, which Migrator.Down refuses to roll back
+	Irreversible bool
+	// Content function to render the SQL of the migration, if it is
+	// written in SQL or Fizz
+	Content func(Migration, *Connection) (string, error)
 	// Runner function to run/execute the migration
 	Runner func(Migration, *Connection) error
 }
@@ -29,6 +40,35 @@ func (mf Migration) Run(c *Connection) error {
 	return mf.Runner(mf, c)
 }
 
+// runMigrationContent is the Runner of the migrations written in SQL or
+// Fizz, executing their Content. The statements of the migrations run
+// outside of a transaction are executed one by one, since some databases,
+// such as PostgreSQL, run the statements sent at once in an implicit
+// transaction.
+func runMigrationContent(mf Migration, tx *Connection) error {
+	if mf.Content == nil {
+		return fmt.Errorf("no content defined for %s", mf.Path)
+	}
+	content, err := mf.Content(mf, tx)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return nil
+	}
+	stmts := []string{content}
+	if mf.NoTransaction {
+		stmts = newSQLScanner(tx.Dialect).split(content)
+	}
+	for _, stmt := range stmts {
+		err = tx.RawQuery(stmt).Exec(nil)
+		if err != nil {
+			return fmt.Errorf("error executing %s, sql: %s: %w", mf.Path, stmt, err)
+		}
+	}
+	return nil
+}
+
 // Migrations is a collection of Migration
 type Migrations []Migration
 
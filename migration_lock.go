@@ -0,0 +1,255 @@
+package pop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gofrs/uuid"
+)
+
+// ErrMigrationLockLost is returned when the migration lock expired while
+// the migrations were running, e.g. because it could not be renewed in
+// time, and may have been taken by another process. The migrations are
+// stopped as soon as the lock is lost.
+var ErrMigrationLockLost = errors.New("the migration lock was lost")
+
+// ErrMigrationLocked is returned when the migrations cannot be run because
+// another process held the migration lock for longer than the
+// "migration_lock_timeout" option of the connection.
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+// migrationLockable is implemented by the dialects which can prevent
+// processes running migrations on the same database at the same time. fn
+// is given the connection to run the migrations with, which is canceled
+// if the lock is lost.
+type migrationLockable interface {
+	LockMigrations(c *Connection, timeout time.Duration, fn func(c *Connection) error) error
+}
+
+// lockMigrations runs fn while holding the migration lock of the database,
+// if the dialect supports it.
+func (c *Connection) lockMigrations(fn func(c *Connection) error) error {
+	l, ok := c.Dialect.(migrationLockable)
+	if !ok {
+		return fn(c)
+	}
+	return l.LockMigrations(c, c.Dialect.Details().MigrationLockTimeout(), fn)
+}
+
+// migrationLockPollInterval is the time to wait between two attempts to get
+// the migration lock, for the dialects which cannot wait on the database.
+var migrationLockPollInterval = 500 * time.Millisecond
+
+// waitForMigrationLock calls try until it gets the lock, fails, or the
+// timeout is reached.
+func waitForMigrationLock(timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for waiting := false; ; waiting = true {
+		ok, err := try()
+		if err != nil {
+			return fmt.Errorf("could not get the migration lock: %w", err)
+		}
+		if ok {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w: gave up after %s", ErrMigrationLocked, timeout)
+		}
+		if !waiting {
+			log(logging.Info, nil, "Migrator: waiting for the migration lock held by another process")
+		}
+		if remaining > migrationLockPollInterval {
+			remaining = migrationLockPollInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// sessionConn is a single connection to the database, for the statements
+// which must run in the same session, such as the ones of advisory locks.
+type sessionConn interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// session returns a connection of the pool of c, or the transaction of c,
+// and a function to release it. The connection is held until it is
+// released, so it fails if the pool cannot open another connection to run
+// the other statements, instead of waiting for it forever.
+func (c *Connection) session(ctx context.Context) (sessionConn, func() error, error) {
+	s := c.Store
+	for {
+		switch st := s.(type) {
+		case contextStore:
+			s = st.store
+			continue
+		case *dB:
+			if max := st.Stats().MaxOpenConnections; max == 1 {
+				return nil, nil, errors.New("could not get a database session: the pool of the connection must allow at least 2 connections")
+			}
+			conn, err := st.Connx(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not get a database session: %w", err)
+			}
+			return conn, conn.Close, nil
+		case *Tx:
+			return st, func() error { return nil }, nil
+		}
+		return nil, nil, fmt.Errorf("could not get a database session from %T", c.Store)
+	}
+}
+
+// sessionGet runs stmt on the session conn and scans its single value into
+// dest.
+func (c *Connection) sessionGet(conn sessionConn, dest interface{}, stmt string, args ...interface{}) error {
+	return logSQL(c.requestID(nil), c, "", stmt, args, func() error {
+		return conn.GetContext(c.Context(), dest, stmt, args...)
+	})
+}
+
+// migrationLockName returns the name of the migration lock of c, unique for
+// each migration table.
+func migrationLockName(c *Connection) string {
+	return "pop_migrations:" + c.MigrationTableName()
+}
+
+// migrationLockKey returns the migration lock of c as a number, for the
+// databases identifying advisory locks by number.
+func migrationLockKey(c *Connection) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(migrationLockName(c)))
+	return int64(h.Sum64())
+}
+
+// migrationLeaseTTL is the time after which a lease is considered abandoned
+// if it has not been renewed, e.g. because its process crashed.
+var migrationLeaseTTL = 30 * time.Second
+
+// migrationLockTableName returns the name of the table holding the lease of
+// the dialects without advisory locks, next to the migration table of c.
+func migrationLockTableName(c *Connection) string {
+	return c.MigrationTableName() + "_lock"
+}
+
+// migrationLease is held by a row of the lock table, owned by the process
+// which inserted it, whose expiry is renewed until it is released. The
+// expiry is computed by the database so that the clocks of the processes
+// do not matter.
+type migrationLease struct {
+	c     *Connection
+	table string
+	owner string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// lockMigrationsWithLease runs fn while holding a lease on the lock table of
+// the migrations of c, which is created if needed. If the lease is lost, the
+// context of the connection given to fn is canceled and
+// ErrMigrationLockLost is returned.
+func lockMigrationsWithLease(c *Connection, timeout time.Duration, fn func(c *Connection) error) error {
+	owner, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	l := &migrationLease{c: c, table: migrationLockTableName(c), owner: owner.String()}
+	stmt := fmt.Sprintf("create table if not exists %s (name varchar(255) primary key, owner varchar(36) not null, expires_at timestamptz not null)", l.table)
+	if err := c.RawQuery(stmt).Exec(nil); err != nil {
+		return fmt.Errorf("could not create the migration lock table: %w", err)
+	}
+	if err := waitForMigrationLock(timeout, l.acquire); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(c.Context())
+	defer cancel(nil)
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.renew(cancel)
+
+	err = fn(c.WithContext(ctx))
+	if cause := context.Cause(ctx); errors.Is(cause, ErrMigrationLockLost) {
+		err = fmt.Errorf("%w: %w", cause, err)
+	}
+	if rerr := l.release(); rerr != nil && err == nil {
+		err = fmt.Errorf("could not release the migration lock: %w", rerr)
+	}
+	return err
+}
+
+// leaseTTLInterval returns the time to live of a lease as an interval
+// literal.
+func leaseTTLInterval() string {
+	return fmt.Sprintf("%d milliseconds", migrationLeaseTTL.Milliseconds())
+}
+
+// acquire inserts the lease row unless another process holds an unexpired
+// one. An expired lease is removed. Losing the race for the lease to
+// another process is not an error.
+func (l *migrationLease) acquire() (bool, error) {
+	name := migrationLockName(l.c)
+	var acquired bool
+	err := l.c.Transaction(nil, func(tx *Connection) error {
+		acquired = false
+		err := tx.RawQuery(fmt.Sprintf("delete from %s where name = ? and expires_at <= now()", l.table), name).Exec(nil)
+		if err != nil {
+			return err
+		}
+		stmt := fmt.Sprintf("insert into %s (name, owner, expires_at) values (?, ?, now() + cast(? as interval)) on conflict (name) do nothing", l.table)
+		n, err := tx.RawQuery(stmt, name, l.owner, leaseTTLInterval()).ExecWithCount(nil)
+		if err != nil {
+			return err
+		}
+		acquired = n == 1
+		return nil
+	})
+	if err != nil && (isUniqueViolation(err) || isSerializationFailure(err)) {
+		return false, nil
+	}
+	return acquired, err
+}
+
+// renew extends the lease regularly until it is released. If the lease was
+// taken over, or could not be renewed before it expired, cancel is called
+// with ErrMigrationLockLost.
+func (l *migrationLease) renew(cancel context.CancelCauseFunc) {
+	defer close(l.done)
+	ticker := time.NewTicker(migrationLeaseTTL / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			stmt := fmt.Sprintf("update %s set expires_at = now() + cast(? as interval) where name = ? and owner = ? and expires_at > now()", l.table)
+			n, err := l.c.RawQuery(stmt, leaseTTLInterval(), migrationLockName(l.c), l.owner).ExecWithCount(nil)
+			switch {
+			case err == nil && n == 0:
+				log(logging.Error, nil, "Migrator: the migration lock expired and may be held by another process, stopping")
+				cancel(ErrMigrationLockLost)
+				return
+			case err == nil:
+				renewed = time.Now()
+			case time.Since(renewed) >= migrationLeaseTTL:
+				log(logging.Error, nil, "Migrator: could not renew the migration lock before it expired, stopping: %v", err)
+				cancel(ErrMigrationLockLost)
+				return
+			default:
+				log(logging.Warn, nil, "Migrator: could not renew the migration lock: %v", err)
+			}
+		}
+	}
+}
+
+// release stops renewing the lease and removes it.
+func (l *migrationLease) release() error {
+	close(l.stop)
+	<-l.done
+	stmt := fmt.Sprintf("delete from %s where name = ? and owner = ?", l.table)
+	return l.c.RawQuery(stmt, migrationLockName(l.c), l.owner).Exec(nil)
+}
@@ -0,0 +1,198 @@
+package pop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConnectionDetails_MigrationLockTimeout(t *testing.T) {
+	r := require.New(t)
+
+	cd := &ConnectionDetails{}
+	r.Equal(5*time.Minute, cd.MigrationLockTimeout())
+
+	cd.Options = map[string]string{"migration_lock_timeout": "10s"}
+	r.Equal(10*time.Second, cd.MigrationLockTimeout())
+
+	cd.Options["migration_lock_timeout"] = "0"
+	r.Equal(time.Duration(0), cd.MigrationLockTimeout())
+
+	cd.Options["migration_lock_timeout"] = "soon"
+	r.Equal(5*time.Minute, cd.MigrationLockTimeout())
+}
+
+func Test_waitForMigrationLock(t *testing.T) {
+	r := require.New(t)
+
+	interval := migrationLockPollInterval
+	migrationLockPollInterval = time.Millisecond
+	defer func() { migrationLockPollInterval = interval }()
+
+	attempts := 0
+	err := waitForMigrationLock(time.Second, func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	r.NoError(err)
+	r.Equal(3, attempts)
+
+	err = waitForMigrationLock(5*time.Millisecond, func() (bool, error) {
+		return false, nil
+	})
+	r.True(errors.Is(err, ErrMigrationLocked))
+
+	boom := errors.New("boom")
+	err = waitForMigrationLock(time.Second, func() (bool, error) {
+		return false, boom
+	})
+	r.True(errors.Is(err, boom))
+	r.False(errors.Is(err, ErrMigrationLocked))
+}
+
+func Test_lockMigrationsWithLease(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	if n := PDB.Dialect.Name(); n != nameCockroach && n != namePostgreSQL {
+		t.Skip("the migration lease relies on PostgreSQL syntax")
+	}
+	r := require.New(t)
+	table := migrationLockTableName(PDB)
+	defer func() {
+		r.NoError(PDB.RawQuery(fmt.Sprintf("drop table if exists %s", table)).Exec(nil))
+	}()
+
+	leases := func() int {
+		n, err := PDB.Q().Count(nil, table)
+		r.NoError(err)
+		return n
+	}
+
+	ran := false
+	err := lockMigrationsWithLease(PDB, 0, func(*Connection) error {
+		ran = true
+		r.Equal(1, leases())
+
+		// another process cannot get the lease while it is held
+		err := lockMigrationsWithLease(PDB, 0, func(*Connection) error {
+			return errors.New("should not run")
+		})
+		r.True(errors.Is(err, ErrMigrationLocked), "%v", err)
+		return nil
+	})
+	r.NoError(err)
+	r.True(ran)
+	r.Equal(0, leases())
+
+	// the migration table is left alone
+	n, err := PDB.Q().Count(nil, PDB.MigrationTableName())
+	r.NoError(err)
+	applied, err := NewMigrator(PDB).Applied()
+	r.NoError(err)
+	r.Len(applied, n)
+
+	// an expired lease is taken over
+	stmt := fmt.Sprintf("insert into %s (name, owner, expires_at) values (?, ?, now() - interval '1 minute')", table)
+	r.NoError(PDB.RawQuery(stmt, migrationLockName(PDB), "crashed").Exec(nil))
+	r.NoError(lockMigrationsWithLease(PDB, 0, func(*Connection) error { return nil }))
+	r.Equal(0, leases())
+}
+
+func Test_lockMigrationsWithLease_Concurrent(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	if n := PDB.Dialect.Name(); n != nameCockroach && n != namePostgreSQL {
+		t.Skip("the migration lease relies on PostgreSQL syntax")
+	}
+	r := require.New(t)
+	table := migrationLockTableName(PDB)
+	defer PDB.RawQuery(fmt.Sprintf("drop table if exists %s", table)).Exec(nil)
+
+	interval := migrationLockPollInterval
+	migrationLockPollInterval = 10 * time.Millisecond
+	defer func() { migrationLockPollInterval = interval }()
+
+	var mu sync.Mutex
+	running, overlapped := 0, false
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			errs <- lockMigrationsWithLease(PDB, time.Minute, func(*Connection) error {
+				mu.Lock()
+				running++
+				overlapped = overlapped || running > 1
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		r.NoError(<-errs)
+	}
+	r.False(overlapped)
+}
+
+func Test_lockMigrationsWithLease_Lost(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	if n := PDB.Dialect.Name(); n != nameCockroach && n != namePostgreSQL {
+		t.Skip("the migration lease relies on PostgreSQL syntax")
+	}
+	r := require.New(t)
+	table := migrationLockTableName(PDB)
+	defer PDB.RawQuery(fmt.Sprintf("drop table if exists %s", table)).Exec(nil)
+
+	ttl := migrationLeaseTTL
+	migrationLeaseTTL = 300 * time.Millisecond
+	defer func() { migrationLeaseTTL = ttl }()
+
+	err := lockMigrationsWithLease(PDB, 0, func(c *Connection) error {
+		// another process takes the lease over
+		r.NoError(PDB.RawQuery(fmt.Sprintf("update %s set owner = ?", table), "other").Exec(nil))
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("the migrations were not stopped")
+		}
+	})
+	r.True(errors.Is(err, ErrMigrationLockLost), "%v", err)
+	r.True(errors.Is(err, context.Canceled), "%v", err)
+}
+
+func Test_Connection_session_Pool(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	db, ok := PDB.Store.(*dB)
+	if !ok {
+		t.Skipf("the store of the connection is a %T", PDB.Store)
+	}
+	defer db.SetMaxOpenConns(db.Stats().MaxOpenConnections)
+
+	db.SetMaxOpenConns(1)
+	_, _, err := PDB.session(PDB.Context())
+	r.Error(err)
+
+	db.SetMaxOpenConns(2)
+	conn, release, err := PDB.session(PDB.Context())
+	r.NoError(err)
+	var one int
+	r.NoError(PDB.sessionGet(conn, &one, "SELECT 1"))
+	r.Equal(1, one)
+	r.NoError(PDB.RawQuery("SELECT 1").Exec(nil))
+	r.NoError(release())
+}
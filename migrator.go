@@ -1,18 +1,25 @@
 package pop
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/Accefy/pop/logging"
 )
 
+// ErrMigrationIncomplete is returned when a migration run outside of a
+// transaction fails. Its statements which succeeded are not rolled back, so
+// the database must be repaired before the migration is run again.
+var ErrMigrationIncomplete = errors.New("migration failed outside of a transaction and may be partially applied")
+
 var mrx = regexp.MustCompile(`^(\d+)_([^.]+)(\.[a-z0-9]+)?\.(up|down)\.(sql|fizz)$`)
 
 // NewMigrator returns a new "blank" migrator. It is recommended
@@ -29,11 +36,28 @@ func NewMigrator(c *Connection) Migrator {
 // It does the actual heavy lifting of running migrations.
 // When building a new migration system, you should embed this
 // type into your migrator.
+//
+// Each migration runs in a transaction, unless the first line of its file
+// is "-- pop:no-transaction", for the statements which cannot run in one.
+// The statements of such a migration are run one by one, so they cannot
+// contain semicolons outside of literals and comments, e.g. in the body of
+// a MySQL trigger.
+//
+// On PostgreSQL, CockroachDB, MySQL and MariaDB, migrations are run
+// while holding a database level lock, so processes migrating the same
+// database at the same time wait for each other, for at most the
+// "migration_lock_timeout" option of the connection. On CockroachDB, the
+// lock is a lease which is renewed while the migrations run; if it is lost,
+// the migrations are stopped and ErrMigrationLockLost is returned.
 type Migrator struct {
 	Connection     *Connection
 	SchemaPath     string
 	UpMigrations   UpMigrations
 	DownMigrations DownMigrations
+	// DryRun makes Up and UpTo print the SQL of the pending migrations
+	// to DryRunOutput, or os.Stdout, without modifying the database.
+	DryRun       bool
+	DryRunOutput io.Writer
 }
 
 func (m Migrator) migrationIsCompatible(d dialect, mi Migration) bool {
@@ -46,8 +70,11 @@ func (m Migrator) migrationIsCompatible(d dialect, mi Migration) bool {
 // UpLogOnly insert pending "up" migrations logs only, without applying the patch.
 // It's used when loading the schema dump, instead of the migrations.
 func (m Migrator) UpLogOnly() error {
-	c := m.Connection
-	return m.exec(func() error {
+	return m.exec(func(m Migrator) error {
+		c := m.Connection
+		if err := m.backfillSchemaMigrations(); err != nil {
+			return err
+		}
 		mtn := c.MigrationTableName()
 		mfs := m.UpMigrations
 		sort.Sort(mfs)
@@ -63,9 +90,8 @@ func (m Migrator) UpLogOnly() error {
 				if exists {
 					continue
 				}
-				_, err = tx.Store.Exec(fmt.Sprintf("insert into %s (version) values ('%s')", mtn, mi.Version))
-				if err != nil {
-					return fmt.Errorf("problem inserting migration version %s: %w", mi.Version, err)
+				if err := recordMigration(tx, mi, nil); err != nil {
+					return err
 				}
 			}
 			return nil
@@ -82,8 +108,14 @@ func (m Migrator) Up() error {
 // UpTo runs up to step "up" migrations and applies them to the database.
 // If step <= 0 all pending migrations are run.
 func (m Migrator) UpTo(step int) (applied int, err error) {
-	c := m.Connection
-	err = m.exec(func() error {
+	if m.DryRun {
+		return 0, m.dryRun(step)
+	}
+	err = m.exec(func(m Migrator) error {
+		c := m.Connection
+		if err := m.backfillSchemaMigrations(); err != nil {
+			return err
+		}
 		mtn := c.MigrationTableName()
 		mfs := m.UpMigrations
 		mfs.Filter(func(mf Migration) bool {
@@ -98,16 +130,8 @@ func (m Migrator) UpTo(step int) (applied int, err error) {
 			if exists {
 				continue
 			}
-			err = c.Transaction(nil, func(tx *Connection) error {
-				err := mi.Run(tx)
-				if err != nil {
-					return err
-				}
-				_, err = tx.Store.Exec(fmt.Sprintf("insert into %s (version) values ('%s')", mtn, mi.Version))
-				if err != nil {
-					return fmt.Errorf("problem inserting migration version %s: %w", mi.Version, err)
-				}
-				return nil
+			err = m.runMigration(mi, func(tx *Connection, duration time.Duration) error {
+				return recordMigration(tx, mi, &duration)
 			})
 			if err != nil {
 				return err
@@ -128,43 +152,99 @@ func (m Migrator) UpTo(step int) (applied int, err error) {
 	return
 }
 
+// dryRun prints the SQL of up to step pending "up" migrations, without
+// running them nor creating the migration table.
+func (m Migrator) dryRun(step int) error {
+	c := m.Connection
+	out := m.DryRunOutput
+	if out == nil {
+		out = os.Stdout
+	}
+	err := c.Open()
+	if err != nil {
+		return fmt.Errorf("could not open connection: %w", err)
+	}
+	mtn := c.MigrationTableName()
+	_, err = c.Store.Exec(fmt.Sprintf("select * from %s", mtn))
+	tracked := err == nil
+
+	mfs := m.UpMigrations
+	mfs.Filter(func(mf Migration) bool {
+		return m.migrationIsCompatible(c.Dialect, mf)
+	})
+	sort.Sort(mfs)
+	pending := 0
+	for _, mi := range mfs.Migrations {
+		if tracked {
+			exists, err := c.Where("version = ?", mi.Version).Exists(mtn)
+			if err != nil {
+				return fmt.Errorf("problem checking for migration version %s: %w", mi.Version, err)
+			}
+			if exists {
+				continue
+			}
+		}
+		_, _ = fmt.Fprintf(out, "-- %s %s (%s)\n", mi.Version, mi.Name, mi.Type)
+		if mi.NoTransaction {
+			_, _ = fmt.Fprintln(out, "-- runs outside of a transaction")
+		}
+		if mi.Content == nil {
+			_, _ = fmt.Fprintf(out, "-- %s cannot be shown, it is not written in SQL or Fizz\n\n", mi.Path)
+		} else {
+			content, err := mi.Content(mi, c)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "%s\n\n", strings.TrimSpace(content))
+		}
+		pending++
+		if step > 0 && pending >= step {
+			break
+		}
+	}
+	if pending == 0 {
+		_, _ = fmt.Fprintln(out, "-- Migrations already up to date, nothing to apply")
+	}
+	return nil
+}
+
 // Down runs pending "down" migrations and rolls back the
-// database by the specified number of steps.
+// database by the specified number of steps. The applied migrations are
+// rolled back from the latest one, and it fails when one of them is an
+// irreversible migration added with RegisterMigration. The applied versions
+// without a "down" migration, e.g. because their files were removed, are
+// skipped.
 func (m Migrator) Down(step int) error {
-	c := m.Connection
-	return m.exec(func() error {
+	return m.exec(func(m Migrator) error {
+		c := m.Connection
 		mtn := c.MigrationTableName()
-		count, err := c.Count(nil, mtn)
+		var versions []string
+		err := c.Store.Select(&versions, fmt.Sprintf("select version from %s order by version desc", mtn))
 		if err != nil {
-			return fmt.Errorf("migration down: unable count existing migration: %w", err)
-		}
-		mfs := m.DownMigrations
-		mfs.Filter(func(mf Migration) bool {
-			return m.migrationIsCompatible(c.Dialect, mf)
-		})
-		sort.Sort(mfs)
-		// skip all ran migration
-		if len(mfs.Migrations) > count {
-			mfs.Migrations = mfs.Migrations[len(mfs.Migrations)-count:]
+			return fmt.Errorf("migration down: unable to list existing migrations: %w", err)
 		}
 		// run only required steps
-		if step > 0 && len(mfs.Migrations) >= step {
-			mfs.Migrations = mfs.Migrations[:step]
+		if step > 0 && len(versions) > step {
+			versions = versions[:step]
 		}
-		for _, mi := range mfs.Migrations {
-			exists, err := c.Where("version = ?", mi.Version).Exists(mtn)
-			if err != nil {
-				return fmt.Errorf("problem checking for migration version %s: %w", mi.Version, err)
-			}
-			if !exists {
-				return fmt.Errorf("migration version %s does not exist", mi.Version)
+		migrations := m.appliedDownMigrations()
+		irreversible := map[string]bool{}
+		for _, mf := range m.UpMigrations.Migrations {
+			if mf.Irreversible && m.migrationIsCompatible(c.Dialect, mf) {
+				irreversible[mf.Version] = true
 			}
-			err = c.Transaction(nil, func(tx *Connection) error {
-				err := mi.Run(tx)
-				if err != nil {
-					return err
+		}
+		for _, version := range versions {
+			mi, ok := migrations[version]
+			if !ok {
+				if irreversible[version] {
+					return fmt.Errorf("migration version %s is irreversible", version)
 				}
-				err = tx.RawQuery(fmt.Sprintf("delete from %s where version = ?", mtn), mi.Version).Exec(nil)
+				log(logging.Warn, nil, "Migrator: skipping migration version %s, which has no down migration", version)
+				continue
+			}
+			err = m.runMigration(mi, func(tx *Connection, _ time.Duration) error {
+				err := tx.RawQuery(fmt.Sprintf("delete from %s where version = ?", mtn), mi.Version).Exec(nil)
 				if err != nil {
 					return fmt.Errorf("problem deleting migration version %s: %w", mi.Version, err)
 				}
@@ -180,6 +260,50 @@ func (m Migrator) Down(step int) error {
 	})
 }
 
+// appliedDownMigrations returns the down migrations compatible with the
+// dialect, by version. For a version with several migrations, the one for
+// the dialect is preferred.
+func (m Migrator) appliedDownMigrations() map[string]Migration {
+	c := m.Connection
+	mfs := DownMigrations{append(Migrations{}, m.DownMigrations.Migrations...)}
+	mfs.Filter(func(mf Migration) bool {
+		return m.migrationIsCompatible(c.Dialect, mf)
+	})
+	sort.Sort(mfs)
+	migrations := make(map[string]Migration, len(mfs.Migrations))
+	for _, mi := range mfs.Migrations {
+		if _, ok := migrations[mi.Version]; !ok {
+			migrations[mi.Version] = mi
+		}
+	}
+	return migrations
+}
+
+// runMigration runs mi, then record to update the migration table, in a
+// transaction unless mi is marked to run outside of one. In that case, the
+// migration table is only updated once mi succeeded.
+func (m Migrator) runMigration(mi Migration, record func(tx *Connection, duration time.Duration) error) error {
+	c := m.Connection
+	if !mi.NoTransaction {
+		return c.Transaction(nil, func(tx *Connection) error {
+			start := time.Now()
+			err := mi.Run(tx)
+			if err != nil {
+				return err
+			}
+			return record(tx, time.Since(start))
+		})
+	}
+
+	start := time.Now()
+	err := mi.Run(c)
+	if err != nil {
+		log(logging.Error, nil, "Migrator: %s failed outside of a transaction and may be partially applied", mi.Path)
+		return fmt.Errorf("%w: %s: %w", ErrMigrationIncomplete, mi.Path, err)
+	}
+	return record(c, time.Since(start))
+}
+
 // Reset the database by running the down migrations followed by the up migrations.
 func (m Migrator) Reset() error {
 	err := m.Down(-1)
@@ -189,8 +313,9 @@ func (m Migrator) Reset() error {
 	return m.Up()
 }
 
-// CreateSchemaMigrations sets up a table to track migrations. This is an idempotent
-// operation.
+// CreateSchemaMigrations sets up a table to track migrations, or adds the
+// columns missing from a table created by a previous version. This is an
+// idempotent operation.
 func CreateSchemaMigrations(c *Connection) error {
 	mtn := c.MigrationTableName()
 	err := c.Open()
@@ -199,7 +324,7 @@ func CreateSchemaMigrations(c *Connection) error {
 	}
 	_, err = c.Store.Exec(fmt.Sprintf("select * from %s", mtn))
 	if err == nil {
-		return nil
+		return upgradeSchemaMigrations(c)
 	}
 
 	return c.Transaction(nil, func(tx *Connection) error {
@@ -264,7 +389,7 @@ func (m Migrator) DumpMigrationSchema() error {
 	return nil
 }
 
-func (m Migrator) exec(fn func() error) error {
+func (m Migrator) exec(fn func(m Migrator) error) error {
 	now := time.Now()
 	defer func() {
 		err := m.DumpMigrationSchema()
@@ -278,7 +403,10 @@ func (m Migrator) exec(fn func() error) error {
 	if err != nil {
 		return fmt.Errorf("Migrator: problem creating schema migrations: %w", err)
 	}
-	return fn()
+	return m.Connection.lockMigrations(func(c *Connection) error {
+		m.Connection = c
+		return fn(m)
+	})
 }
 
 func printTimer(timerStart time.Time) {
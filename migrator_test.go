@@ -0,0 +1,130 @@
+package pop
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Migrator_DryRun(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "dry_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS dry_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_dry_widgets.up.fizz", `create_table("dry_widgets") {
+	t.Column("name", "string")
+}`)
+		writeMigration(t, dir, "20240102000000_dry_index.up.sql", "CREATE INDEX dry_widgets_name_idx ON dry_widgets (name);")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.NoError(fm.RegisterMigration("20240103000000", "seed_dry_widgets", func(tx *Connection) error {
+			return nil
+		}, nil))
+		fm.DryRun = true
+		out := &bytes.Buffer{}
+		fm.DryRunOutput = out
+
+		r.NoError(fm.Up())
+		r.Contains(out.String(), "-- 20240101000000 dry_widgets (fizz)\n")
+		r.Contains(out.String(), "dry_widgets")
+		r.Contains(out.String(), "-- 20240102000000 dry_index (sql)\nCREATE INDEX dry_widgets_name_idx ON dry_widgets (name);\n")
+		r.Contains(out.String(), "-- 20240103000000 seed_dry_widgets (go)\n")
+
+		// neither the migrations nor the migration table were created
+		_, err = PDB.Store.Exec("select * from dry_schema_migration")
+		r.Error(err)
+		_, err = PDB.Store.Exec("select * from dry_widgets")
+		r.Error(err)
+
+		// only the pending migrations are printed
+		fm.DryRun = false
+		_, err = fm.UpTo(1)
+		r.NoError(err)
+		fm.DryRun = true
+		out.Reset()
+		_, err = fm.UpTo(1)
+		r.NoError(err)
+		r.NotContains(out.String(), "dry_widgets (fizz)")
+		r.Contains(out.String(), "-- 20240102000000 dry_index (sql)\n")
+		r.NotContains(out.String(), "seed_dry_widgets")
+	})
+}
+
+func Test_Migrator_NoTransaction(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "notx_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS notx_widgets").Exec(nil)
+		defer PDB.RawQuery("DROP TABLE IF EXISTS notx_gadgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_notx_widgets.up.fizz", "-- pop:no-transaction\ncreate_table(\"notx_widgets\") {\n\tt.Column(\"name\", \"string\")\n}")
+		writeMigration(t, dir, "20240101000000_notx_widgets.down.sql", "-- pop:no-transaction\nDROP TABLE notx_widgets;")
+		writeMigration(t, dir, "20240102000000_notx_gadgets.up.sql", "-- pop:no-transaction\nCREATE TABLE notx_gadgets (id INT);\nINSERT INTO notx_missing (id) VALUES (1);")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.True(fm.UpMigrations.Migrations[0].NoTransaction)
+		r.True(fm.DownMigrations.Migrations[0].NoTransaction)
+
+		_, err = fm.UpTo(1)
+		r.NoError(err)
+		exists, err := PDB.Where("version = ?", "20240101000000").Exists("notx_schema_migration")
+		r.NoError(err)
+		r.True(exists)
+
+		// the statements before the failure are kept, but the migration is
+		// not recorded
+		err = fm.Up()
+		r.True(errors.Is(err, ErrMigrationIncomplete), "%v", err)
+		_, err = PDB.Store.Exec("select * from notx_gadgets")
+		r.NoError(err)
+		exists, err = PDB.Where("version = ?", "20240102000000").Exists("notx_schema_migration")
+		r.NoError(err)
+		r.False(exists)
+
+		r.NoError(fm.Down(1))
+		_, err = PDB.Store.Exec("select * from notx_widgets")
+		r.Error(err)
+	})
+}
+
+func Test_Migrator_NoTransaction_Postgres(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	if PDB.Dialect.Name() != namePostgreSQL {
+		t.Skip("CREATE INDEX CONCURRENTLY is specific to PostgreSQL")
+	}
+	r := require.New(t)
+
+	withMigrationTable(t, "notx_pg_schema_migration", func() {
+		defer PDB.RawQuery("DROP TABLE IF EXISTS notx_pg_widgets").Exec(nil)
+
+		dir := t.TempDir()
+		writeMigration(t, dir, "20240101000000_notx_pg_widgets.up.sql", "CREATE TABLE notx_pg_widgets (id INT, name VARCHAR(255));")
+		writeMigration(t, dir, "20240102000000_notx_pg_indexes.up.sql", "-- pop:no-transaction\nCREATE INDEX CONCURRENTLY notx_pg_widgets_id_idx ON notx_pg_widgets (id);\nCREATE INDEX CONCURRENTLY notx_pg_widgets_name_idx ON notx_pg_widgets (name);\n")
+
+		fm, err := NewFileMigrator(dir, PDB)
+		r.NoError(err)
+		fm.SchemaPath = ""
+		r.NoError(fm.Up())
+
+		var indexes []string
+		r.NoError(PDB.Store.Select(&indexes, "SELECT indexname FROM pg_indexes WHERE tablename = 'notx_pg_widgets' ORDER BY indexname"))
+		r.Equal([]string{"notx_pg_widgets_id_idx", "notx_pg_widgets_name_idx"}, indexes)
+	})
+}
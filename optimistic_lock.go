@@ -0,0 +1,80 @@
+package pop
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Accefy/pop/columns"
+)
+
+// ErrStaleObject is returned when updating a versioned model which has been
+// updated or deleted since it was loaded.
+var ErrStaleObject = errors.New("stale object: the record was modified concurrently")
+
+// Versionable interface allows for the customization of the column used
+// for optimistic locking. Models with a `LockVersion` field are versioned
+// using its column, without having to implement `Versionable`.
+//
+// The column must be mapped to an integer field. Each update of the model
+// checks that the version in the database matches the field, and increments
+// both, or fails with `ErrStaleObject`.
+type Versionable interface {
+	VersionColumn() string
+}
+
+// versionColumn returns the column used to version the model, or an empty
+// string if the model is not versioned.
+func (m *Model) versionColumn() string {
+	t := reflect.TypeOf(m.Value)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	if v, ok := reflect.New(t).Interface().(Versionable); ok {
+		return v.VersionColumn()
+	}
+
+	field, ok := t.FieldByName("LockVersion")
+	if !ok {
+		return ""
+	}
+	tag := columns.TagsFor(field).Find("db")
+	if tag.Ignored() {
+		return ""
+	}
+	return tag.Value
+}
+
+// versionField returns the field mapped to the version column.
+func (m *Model) versionField(column string) (reflect.Value, error) {
+	fbn := fieldMapper.FieldByName(reflect.Indirect(reflect.ValueOf(m.Value)), column)
+	if !fbn.IsValid() {
+		return fbn, fmt.Errorf("model %T does not have a field for column %s", m.Value, column)
+	}
+	switch fbn.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fbn, nil
+	}
+	return fbn, fmt.Errorf("version column %s of model %T must be an integer; got %s", column, m.Value, fbn.Type())
+}
+
+// versionedUpdate returns the SET and WHERE parts of the update statement
+// of a versioned model, with the version column incremented and checked.
+func versionedUpdate(model *Model, column string, cols columns.Columns, quoter quotable) (string, string) {
+	w := cols.Writeable()
+	w.Remove(column)
+	set := w.QuotedUpdateString(quoter)
+	if set != "" {
+		set += ", "
+	}
+	set += fmt.Sprintf("%s = %s + 1", quoter.Quote(column), quoter.Quote(column))
+	where := fmt.Sprintf("%s AND %s.%s = :%s", model.WhereNamedID(), model.Alias(), column, column)
+	return set, where
+}
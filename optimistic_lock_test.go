@@ -0,0 +1,76 @@
+package pop
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type revision struct {
+	ID  int    `db:"id"`
+	Rev int64  `db:"rev"`
+	Doc string `db:"doc"`
+}
+
+func (revision) VersionColumn() string {
+	return "rev"
+}
+
+func Test_Model_versionColumn(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("lock_version", NewModel(&Document{}, nil).versionColumn())
+	r.Equal("lock_version", NewModel(&[]Document{}, nil).versionColumn())
+	r.Equal("rev", NewModel(&revision{}, nil).versionColumn())
+	r.Equal("", NewModel(&User{}, nil).versionColumn())
+	r.Equal("", NewModel("documents", nil).versionColumn())
+}
+
+func Test_versionedUpdate(t *testing.T) {
+	r := require.New(t)
+
+	m := NewModel(&revision{}, nil)
+	cols := m.Columns()
+	cols.Remove("id")
+	set, where := versionedUpdate(m, "rev", cols, &postgresql{})
+	r.Equal(`"doc" = :doc, "rev" = "rev" + 1`, set)
+	r.Equal("revisions.id = :id AND revisions.rev = :rev", where)
+}
+
+func Test_OptimisticLocking(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		d := &Document{Title: "draft"}
+		r.NoError(tx.Create(nil, d))
+		r.Equal(0, d.LockVersion)
+
+		stale := &Document{}
+		r.NoError(tx.Find(nil, stale, d.ID))
+
+		d.Title = "final"
+		r.NoError(tx.Update(nil, d))
+		r.Equal(1, d.LockVersion)
+
+		stale.Title = "other"
+		err := tx.Update(nil, stale)
+		r.True(errors.Is(err, ErrStaleObject))
+		r.Equal(0, stale.LockVersion)
+
+		r.NoError(tx.UpdateColumns(nil, d, "title"))
+		r.Equal(2, d.LockVersion)
+
+		fresh := &Document{}
+		r.NoError(tx.Find(nil, fresh, d.ID))
+		r.Equal("final", fresh.Title)
+		r.Equal(2, fresh.LockVersion)
+
+		r.NoError(tx.Destroy(nil, fresh))
+		err = tx.Update(nil, d)
+		r.True(errors.Is(err, ErrStaleObject))
+	})
+}
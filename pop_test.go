@@ -276,6 +276,65 @@ type Song struct {
 	ComposedBy   Composer  `belongs_to:"composer"`
 }
 
+type Subscription struct {
+	Tracked
+	ID        int       `db:"id"`
+	Email     string    `db:"email"`
+	Plan      string    `db:"plan"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+	Callbacks []string  `db:"-"`
+}
+
+func (s *Subscription) BeforeSave(tx *Connection) error {
+	s.Callbacks = append(s.Callbacks, "BeforeSave")
+	return nil
+}
+
+func (s *Subscription) AfterSave(tx *Connection) error {
+	s.Callbacks = append(s.Callbacks, "AfterSave")
+	return nil
+}
+
+type Note struct {
+	ID         int        `db:"id"`
+	NotebookID nulls.Int  `db:"notebook_id"`
+	Body       string     `db:"body"`
+	DeletedAt  nulls.Time `db:"deleted_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+}
+
+type Notes []Note
+
+type ArchivableNote struct {
+	ID         int        `db:"id"`
+	Body       string     `db:"body"`
+	ArchivedAt *time.Time `db:"archived_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+}
+
+func (ArchivableNote) DeletedAtColumn() string {
+	return "archived_at"
+}
+
+type Notebook struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	Notes     Notes     `has_many:"notes" order_by:"id asc"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type Document struct {
+	ID          int       `db:"id"`
+	Title       string    `db:"title"`
+	LockVersion int       `db:"lock_version"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
 type Composer struct {
 	ID        int       `db:"id"`
 	Name      string    `db:"name"`
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/Accefy/pop/internal/defaults"
-	"github.com/Accefy/pop/logging"
 	"github.com/gobuffalo/flect"
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
@@ -458,7 +457,10 @@ func preloadManyToMany(tx *Connection, asoc *AssociationMetaInfo, mmi *ModelMeta
 	}
 
 	sql := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s in (?)", modelAssociationName, assocFkName, manyToManyTableName, modelAssociationName)
-	sql, args, _ := sqlx.In(sql, ids)
+	sql, args, err := newSQLScanner(tx.Dialect).bind(sql, []interface{}{ids})
+	if err != nil {
+		return err
+	}
 	sql = tx.Dialect.TranslateSQL(sql)
 
 	cn, err := tx.Store.Transaction()
@@ -466,8 +468,11 @@ func preloadManyToMany(tx *Connection, asoc *AssociationMetaInfo, mmi *ModelMeta
 		return err
 	}
 
-	txlog(logging.SQL, nil, cn, sql, args...)
-	rows, err := cn.Queryx(sql, args...)
+	var rows *sqlx.Rows
+	err = logSQL(tx.requestID(nil), cn, manyToManyTableName, sql, args, func() error {
+		rows, err = cn.Queryx(sql, args...)
+		return err
+	})
 	if err != nil {
 		return err
 	}
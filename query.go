@@ -1,9 +1,6 @@
 package pop
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/Accefy/pop/logging"
 )
 
@@ -31,8 +28,16 @@ type Query struct {
 	groupClauses            groupClauses
 	havingClauses           havingClauses
 	Paginator               *Paginator
+	CursorPaginator         *CursorPaginator
 	Connection              *Connection
 	Operation               operation
+	usePrimary              bool
+	rowLock                 *rowLock
+	deletedScope            deletedScope
+	table                   interface{}
+	withClauses             clauses
+	fromQuery               *fromQueryClause
+	err                     error
 }
 
 // Clone will fill targetQ query with the connection used in q, if
@@ -51,12 +56,28 @@ func (q *Query) Clone(targetQ *Query) {
 	targetQ.havingClauses = q.havingClauses
 	targetQ.addColumns = q.addColumns
 	targetQ.Operation = q.Operation
+	targetQ.usePrimary = q.usePrimary
+	targetQ.deletedScope = q.deletedScope
+	targetQ.table = q.table
+	targetQ.withClauses = q.withClauses
+	targetQ.fromQuery = q.fromQuery
+	targetQ.err = q.err
+
+	if q.rowLock != nil {
+		rowLock := *q.rowLock
+		targetQ.rowLock = &rowLock
+	}
 
 	if q.Paginator != nil {
 		paginator := *q.Paginator
 		targetQ.Paginator = &paginator
 	}
 
+	if q.CursorPaginator != nil {
+		paginator := *q.CursorPaginator
+		targetQ.CursorPaginator = &paginator
+	}
+
 	if q.Connection != nil {
 		connection := *q.Connection
 		targetQ.Connection = &connection
@@ -123,7 +144,7 @@ func (q *Query) disableEager() {
 // arguments.
 //
 //	c.Where("id = ?", 1)
-//	q.Where("id in (?)", 1, 2, 3)
+//	c.Where("id in (?)", 1, 2, 3)
 func (c *Connection) Where(stmt string, args ...interface{}) *Query {
 	q := Q(c)
 	return q.Where(stmt, args...)
@@ -139,15 +160,9 @@ func (q *Query) Where(stmt string, args ...interface{}) *Query {
 		log(logging.Warn, nil, "Query is setup to use raw SQL")
 		return q
 	}
-	if inRegex.MatchString(stmt) {
-		var inq []string
-		for i := 0; i < len(args); i++ {
-			inq = append(inq, "?")
-		}
-		qs := fmt.Sprintf("(%s)", strings.Join(inq, ","))
-		stmt = inRegex.ReplaceAllString(stmt, " IN "+qs)
+	if cl, ok := q.whereClause(stmt, args); ok {
+		q.whereClauses = append(q.whereClauses, cl)
 	}
-	q.whereClauses = append(q.whereClauses, clause{stmt, args})
 	return q
 }
 
@@ -220,6 +235,16 @@ func (q Query) ToSQL(model *Model, addColumns ...string) (string, []interface{})
 	return sb.String(), sb.Args()
 }
 
+// toSQL is like ToSQL, but also returns the error of binding the arguments
+// to the generated SQL, if any.
+func (q Query) toSQL(model *Model, addColumns ...string) (string, []interface{}, error) {
+	sb := q.toSQLBuilder(model, addColumns...)
+	if model == nil && (q.RawSQL == nil || q.RawSQL.Fragment == "") {
+		return "", nil, nil
+	}
+	return sb.String(), sb.Args(), sb.Err()
+}
+
 // ToSQLBuilder returns a new `SQLBuilder` that can be used to generate SQL,
 // get arguments, and more.
 func (q Query) toSQLBuilder(model *Model, addColumns ...string) *sqlBuilder {
@@ -0,0 +1,85 @@
+package pop
+
+import (
+	"fmt"
+
+	"github.com/Accefy/pop/logging"
+)
+
+// rowLock describes the row locking clause of a SELECT query.
+type rowLock struct {
+	// Strength is either "UPDATE" or "SHARE".
+	Strength string
+	// Wait is either empty, "NOWAIT" or "SKIP LOCKED".
+	Wait string
+}
+
+// ForUpdate locks the rows returned by the query against concurrent updates,
+// until the end of the current transaction.
+//
+//	tx.Where("status = ?", "pending").ForUpdate().SkipLocked().First(nil, &job)
+func (q *Query) ForUpdate() *Query {
+	return q.lockRows("UPDATE", "")
+}
+
+// ForShare locks the rows returned by the query against concurrent updates,
+// while allowing other transactions to lock them for share too.
+//
+//	tx.Where("id = ?", id).ForShare().First(nil, &account)
+func (q *Query) ForShare() *Query {
+	return q.lockRows("SHARE", "")
+}
+
+// SkipLocked skips the rows which are already locked by another transaction,
+// instead of waiting for them. It implies ForUpdate if no lock is set yet.
+//
+//	tx.Where("status = ?", "pending").ForUpdate().SkipLocked().First(nil, &job)
+func (q *Query) SkipLocked() *Query {
+	return q.lockRows("", "SKIP LOCKED")
+}
+
+// NoWait makes the query fail instead of waiting, if one of the rows is
+// already locked by another transaction. It implies ForUpdate if no lock is
+// set yet.
+//
+//	tx.Where("id = ?", id).ForUpdate().NoWait().First(nil, &account)
+func (q *Query) NoWait() *Query {
+	return q.lockRows("", "NOWAIT")
+}
+
+func (q *Query) lockRows(strength string, wait string) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if q.rowLock == nil {
+		q.rowLock = &rowLock{Strength: "UPDATE"}
+	}
+	if strength != "" {
+		q.rowLock.Strength = strength
+	}
+	if wait != "" {
+		q.rowLock.Wait = wait
+	}
+	return q
+}
+
+// checkRowLock returns an error if the query locks rows, but the dialect of
+// the connection does not support it.
+func (q *Query) checkRowLock() error {
+	if q.rowLock == nil {
+		return nil
+	}
+	if _, ok := q.Connection.Dialect.(lockable); !ok {
+		return fmt.Errorf("%s does not support row locking clauses", q.Connection.Dialect.Name())
+	}
+	return nil
+}
+
+func genericLockClause(l rowLock) string {
+	s := "FOR " + l.Strength
+	if l.Wait != "" {
+		s += " " + l.Wait
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package pop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Query_RowLock_ToSQL(t *testing.T) {
+	r := require.New(t)
+
+	pg, err := newPostgreSQL(&ConnectionDetails{Dialect: namePostgreSQL})
+	r.NoError(err)
+	my, err := newMySQL(&ConnectionDetails{Dialect: nameMySQL})
+	r.NoError(err)
+	maria, err := newMySQL(&ConnectionDetails{Dialect: nameMariaDB})
+	r.NoError(err)
+
+	table := []struct {
+		dialect dialect
+		query   func(q *Query) *Query
+		sql     string
+	}{
+		{pg, func(q *Query) *Query { return q.ForUpdate() }, "SELECT enemies.A FROM enemies AS enemies FOR UPDATE"},
+		{pg, func(q *Query) *Query { return q.ForShare() }, "SELECT enemies.A FROM enemies AS enemies FOR SHARE"},
+		{pg, func(q *Query) *Query { return q.SkipLocked() }, "SELECT enemies.A FROM enemies AS enemies FOR UPDATE SKIP LOCKED"},
+		{pg, func(q *Query) *Query { return q.NoWait().ForShare() }, "SELECT enemies.A FROM enemies AS enemies FOR SHARE NOWAIT"},
+		{pg, func(q *Query) *Query {
+			return q.Where("A = ?", "x").Order("A").Limit(5).ForUpdate().SkipLocked()
+		}, "SELECT enemies.A FROM enemies AS enemies WHERE A = $1 ORDER BY A LIMIT 5 FOR UPDATE SKIP LOCKED"},
+		{my, func(q *Query) *Query { return q.ForShare().NoWait() }, "SELECT enemies.A FROM enemies AS enemies FOR SHARE NOWAIT"},
+		{maria, func(q *Query) *Query { return q.ForShare() }, "SELECT enemies.A FROM enemies AS enemies LOCK IN SHARE MODE"},
+		{maria, func(q *Query) *Query { return q.ForUpdate().SkipLocked() }, "SELECT enemies.A FROM enemies AS enemies FOR UPDATE SKIP LOCKED"},
+	}
+
+	for _, tt := range table {
+		c := &Connection{Dialect: tt.dialect}
+		m := NewModel(&Enemy{}, context.Background())
+		sql, _ := tt.query(Q(c)).ToSQL(m)
+		r.Equal(tt.sql, sql)
+	}
+}
+
+func Test_Query_RowLock_Clone(t *testing.T) {
+	r := require.New(t)
+
+	q := Q(&Connection{}).ForUpdate()
+	c := &Query{}
+	q.Clone(c)
+	c.NoWait()
+	r.Equal(rowLock{Strength: "UPDATE"}, *q.rowLock)
+	r.Equal(rowLock{Strength: "UPDATE", Wait: "NOWAIT"}, *c.rowLock)
+}
@@ -0,0 +1,124 @@
+package pop
+
+import (
+	"fmt"
+
+	"github.com/Accefy/pop/logging"
+)
+
+// fromQueryClause is a sub-query selected from instead of the table of the
+// model.
+type fromQueryClause struct {
+	clause
+	As string
+}
+
+// Table sets the model, or the name of the table, a query selects from when
+// it is used as a sub-query of another query.
+//
+//	sub := tx.Q().Table(&Order{}).Select("user_id").Where("total > ?", 100)
+//	tx.WhereIn("id", sub).All(nil, &users)
+func (q *Query) Table(model interface{}) *Query {
+	q.table = model
+	return q
+}
+
+// WhereIn will append a `column IN (subQuery)` clause to the query.
+//
+//	sub := tx.Q().Table("orders").Select("user_id").Where("total > ?", 100)
+//	tx.Where("active = ?", true).WhereIn("id", sub)
+//	// WHERE active = ? AND id IN (SELECT user_id FROM orders AS orders WHERE total > ?)
+func (q *Query) WhereIn(column string, subQuery *Query) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.subquery(subQuery); ok {
+		cl.Fragment = fmt.Sprintf("%s IN %s", column, cl.Fragment)
+		q.whereClauses = append(q.whereClauses, cl)
+	}
+	return q
+}
+
+// WhereExists will append an `EXISTS (subQuery)` clause to the query.
+//
+//	sub := tx.Q().Table("orders").Where("orders.user_id = users.id")
+//	tx.WhereExists(sub).All(nil, &users)
+func (q *Query) WhereExists(subQuery *Query) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.subquery(subQuery); ok {
+		cl.Fragment = fmt.Sprintf("EXISTS %s", cl.Fragment)
+		q.whereClauses = append(q.whereClauses, cl)
+	}
+	return q
+}
+
+// With will add a common table expression named name to the query, which
+// can then be used as a table, e.g. in a join or another sub-query.
+//
+//	big := tx.Q().Table("orders").Where("total > ?", 100)
+//	tx.With("big_orders", big).Join("big_orders", "big_orders.user_id = users.id")
+//	// WITH big_orders AS (SELECT ... FROM orders AS orders WHERE total > ?) SELECT ...
+func (q *Query) With(name string, subQuery *Query) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.subquery(subQuery); ok {
+		cl.Fragment = fmt.Sprintf("%s AS %s", name, cl.Fragment)
+		q.withClauses = append(q.withClauses, cl)
+	}
+	return q
+}
+
+// From will make the query select from subQuery, as alias, instead of the
+// table of the model. The sub-query must select the columns of the model.
+//
+//	recent := tx.Q().Table(&User{}).Order("created_at desc").Limit(10)
+//	tx.From(recent, "recent_users").Order("name").All(nil, &users)
+//	// SELECT recent_users.id, ... FROM (SELECT ...) AS recent_users ORDER BY name
+func (q *Query) From(subQuery *Query, alias string) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.subquery(subQuery); ok {
+		q.fromQuery = &fromQueryClause{clause: cl, As: alias}
+	}
+	return q
+}
+
+// subquery builds subQuery as a parenthesized clause, keeping its arguments
+// to be bound along the ones of q. If it cannot be built, the error is kept
+// to be returned when q is executed.
+func (q *Query) subquery(subQuery *Query) (clause, bool) {
+	var m *Model
+	if subQuery.RawSQL.Fragment == "" {
+		if subQuery.table == nil {
+			q.setErr(fmt.Errorf("sub-query has no table: use Query.Table to set it"))
+			return clause{}, false
+		}
+		m = NewModel(subQuery.table, q.Connection.Context())
+	}
+
+	sb := subQuery.toSQLBuilder(m)
+	sb.build()
+	if sb.err != nil {
+		q.setErr(fmt.Errorf("could not build sub-query: %w", sb.err))
+		return clause{}, false
+	}
+	return clause{
+		Fragment:  fmt.Sprintf("(%s)", sb.sql),
+		Arguments: sb.args,
+	}, true
+}
+
+// setErr keeps the first error of building the query.
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
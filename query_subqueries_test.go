@@ -0,0 +1,82 @@
+package pop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Subqueries_ToSQL(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	m := NewModel(new(Enemy), context.Background())
+
+	sub := Q(PDB).Table("songs").Select("u_id").Where("title = ?", "A")
+	sql, args := PDB.Where("a = ?", 1).WhereIn("id", sub).Where("b = ?", 2).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE a = ? AND id IN (SELECT u_id FROM songs AS songs WHERE title = ?) AND b = ?"), sql)
+	r.Equal([]interface{}{1, "A", 2}, args)
+
+	sub = Q(PDB).Table("songs").Select("id").Where("songs.u_id = enemies.id")
+	sql, _ = Q(PDB).WhereExists(sub).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE EXISTS (SELECT id FROM songs AS songs WHERE songs.u_id = enemies.id)"), sql)
+
+	sub = Q(PDB).Table("songs").Select("u_id").Where("title IN (?)", []string{"A", "B"})
+	sql, args = Q(PDB).With("picked", sub).Join("picked", "picked.u_id = enemies.id").Where("a = ?", 1).ToSQL(m)
+	r.Equal(ts("WITH picked AS (SELECT u_id FROM songs AS songs WHERE title IN (?,?)) SELECT enemies.A FROM enemies AS enemies JOIN picked ON picked.u_id = enemies.id WHERE a = ?"), sql)
+	r.Equal([]interface{}{"A", "B", 1}, args)
+
+	sub = Q(PDB).Table(new(Enemy)).Where("a != ?", "x")
+	sql, args = Q(PDB).From(sub, "e").Where("e.A = ?", "y").ToSQL(m)
+	r.Equal(ts("SELECT e.A FROM (SELECT enemies.A FROM enemies AS enemies WHERE a != ?) AS e WHERE e.A = ?"), sql)
+	r.Equal([]interface{}{"x", "y"}, args)
+
+	sub = Q(PDB).RawQuery("SELECT u_id FROM songs WHERE title = ?", "A")
+	sql, args = Q(PDB).WhereIn("id", sub).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE id IN (SELECT u_id FROM songs WHERE title = ?)"), sql)
+	r.Equal([]interface{}{"A"}, args)
+
+	_, _, err := Q(PDB).WhereIn("id", Q(PDB).Select("u_id")).toSQL(m)
+	r.Error(err)
+}
+
+func Test_Subqueries(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		mark := &User{Name: nulls.NewString("Mark")}
+		r.NoError(tx.Create(nil, mark))
+		jane := &User{Name: nulls.NewString("Jane")}
+		r.NoError(tx.Create(nil, jane))
+		r.NoError(tx.Create(nil, &Book{Title: "Pop Book", Isbn: "PB1", UserID: nulls.NewInt(mark.ID)}))
+
+		books := tx.Q().Table(&Book{}).Select("user_id").Where("title = ?", "Pop Book")
+
+		users := []User{}
+		r.NoError(tx.Q().WhereIn("id", books).All(nil, &users))
+		r.Len(users, 1)
+		r.Equal(mark.ID, users[0].ID)
+
+		// eager loading works through sub-queries
+		r.NoError(tx.Q().Eager("Books").WhereExists(tx.Q().Table("books").Select("id").Where("books.user_id = users.id")).All(nil, &users))
+		r.Len(users, 1)
+		r.Len(users[0].Books, 1)
+
+		r.NoError(tx.Q().With("readers", books).Where("id NOT IN (SELECT user_id FROM readers)").All(nil, &users))
+		r.Len(users, 1)
+		r.Equal(jane.ID, users[0].ID)
+
+		named := tx.Q().Table(&User{}).Where("name IS NOT NULL")
+		q := tx.Q().From(named, "named_users").Order("named_users.name").Paginate(1, 1)
+		r.NoError(q.All(nil, &users))
+		r.Len(users, 1)
+		r.Equal("Jane", users[0].Name.String)
+		r.Equal(2, q.Paginator.TotalEntriesSize)
+	})
+}
@@ -0,0 +1,272 @@
+package pop
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Accefy/pop/columns"
+	"github.com/Accefy/pop/logging"
+)
+
+// Eq is a condition matching the columns to the given values. Multiple
+// columns are combined with AND, a nil value, or nil pointer, matches NULL
+// and a slice matches any of its values.
+//
+//	q.WhereCond(pop.Eq{"status": "active", "deleted_at": nil})
+//	q.WhereCond(pop.Eq{"id": []int{1, 2, 3}})
+type Eq map[string]interface{}
+
+// WhereCond will create a query and append the where clause of a condition
+// to it. See `Query.WhereCond`.
+//
+//	c.WhereCond(pop.Eq{"status": "active"})
+func (c *Connection) WhereCond(cond interface{}) *Query {
+	return Q(c).WhereCond(cond)
+}
+
+// WhereCond will append the where clause of a condition to the query. The
+// condition is an `Eq`, a map of column names to values, or a struct whose
+// non-zero fields are matched. The column names are quoted.
+//
+//	q.WhereCond(pop.Eq{"status": "active"})
+//	q.WhereCond(&User{Email: "mark@example.com"})
+//
+// A condition of any other type makes the query fail when it is executed.
+func (q *Query) WhereCond(cond interface{}) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.whereClause(cond, nil); ok {
+		q.whereClauses = append(q.whereClauses, cl)
+	}
+	return q
+}
+
+// Or will combine the last where clause of the query and the given one with
+// OR. You may use `?` in place of arguments, or pass a condition, as for
+// `WhereCond`. The earlier where clauses are kept as they are.
+//
+//	q.Where("published = ?", true).Where("status = ?", "active").Or("owner_id = ?", 1)
+//	// WHERE published = ? AND (status = ? OR owner_id = ?)
+func (q *Query) Or(stmt interface{}, args ...interface{}) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.whereClause(stmt, args); ok {
+		q.or(cl)
+	}
+	return q
+}
+
+// WhereGroup will append the where clauses added by fn to the query as a
+// single, parenthesized clause.
+//
+//	q.Where("published = ?", true).WhereGroup(func(q *pop.Query) {
+//		q.Where("author_id = ?", 1).Or("editor_id = ?", 1)
+//	})
+//	// WHERE published = ? AND (author_id = ? OR editor_id = ?)
+func (q *Query) WhereGroup(fn func(q *Query)) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.group(fn); ok {
+		q.whereClauses = append(q.whereClauses, cl)
+	}
+	return q
+}
+
+// OrGroup will combine the last where clause of the query and the ones
+// added by fn with OR.
+//
+//	q.Where("published = ?", true).OrGroup(func(q *pop.Query) {
+//		q.Where("author_id = ?", 1).Where("draft = ?", true)
+//	})
+//	// WHERE (published = ? OR (author_id = ? AND draft = ?))
+func (q *Query) OrGroup(fn func(q *Query)) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.group(fn); ok {
+		q.or(cl)
+	}
+	return q
+}
+
+// Not will append a negated where clause to the query. You may use `?` in
+// place of arguments, or pass a condition, as for `WhereCond`.
+//
+//	q.Not("status = ?", "archived")
+//	q.Not(pop.Eq{"id": []int{1, 2, 3}})
+func (q *Query) Not(stmt interface{}, args ...interface{}) *Query {
+	if q.RawSQL.Fragment != "" {
+		log(logging.Warn, nil, "Query is setup to use raw SQL")
+		return q
+	}
+	if cl, ok := q.whereClause(stmt, args); ok {
+		cl.Fragment = fmt.Sprintf("NOT (%s)", cl.Fragment)
+		q.whereClauses = append(q.whereClauses, cl)
+	}
+	return q
+}
+
+// or replaces the last where clause of the query with a clause combining it
+// with the given one. The where clauses are copied, since they may be shared
+// with clones of the query.
+func (q *Query) or(cl clause) *Query {
+	n := len(q.whereClauses)
+	if n == 0 {
+		q.whereClauses = append(q.whereClauses, cl)
+		return q
+	}
+	last := q.whereClauses[n-1]
+	q.whereClauses = append(append(clauses{}, q.whereClauses[:n-1]...), clause{
+		Fragment:  fmt.Sprintf("(%s OR %s)", parenthesizeOr(last.Fragment), parenthesizeOr(cl.Fragment)),
+		Arguments: append(append([]interface{}{}, last.Arguments...), cl.Arguments...),
+	})
+	return q
+}
+
+// group runs fn on an empty query of the same connection and returns its
+// where clauses as a single clause. It returns false if fn added none.
+func (q *Query) group(fn func(q *Query)) (clause, bool) {
+	gq := Q(q.Connection)
+	fn(gq)
+	if gq.err != nil {
+		q.setErr(gq.err)
+		return clause{}, false
+	}
+	if len(gq.whereClauses) == 0 {
+		return clause{}, false
+	}
+	fragment := gq.whereClauses.Join(" AND ")
+	if len(gq.whereClauses) > 1 || !isParenthesized(fragment) {
+		fragment = fmt.Sprintf("(%s)", fragment)
+	}
+	return clause{
+		Fragment:  fragment,
+		Arguments: gq.whereClauses.Args(),
+	}, true
+}
+
+// isParenthesized reports whether fragment is entirely wrapped in a single
+// pair of parentheses.
+func isParenthesized(fragment string) bool {
+	if !strings.HasPrefix(fragment, "(") || !strings.HasSuffix(fragment, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range fragment {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(fragment)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+var orRegex = regexp.MustCompile(`(?i)\sor\s`)
+
+// parenthesizeOr wraps fragment in parentheses if it contains an OR which
+// would otherwise bind differently once combined with other clauses.
+func parenthesizeOr(fragment string) string {
+	if orRegex.MatchString(fragment) && !isParenthesized(fragment) {
+		return fmt.Sprintf("(%s)", fragment)
+	}
+	return fragment
+}
+
+// whereClause builds the where clause of a SQL fragment with arguments, or
+// of a condition such as `Eq`, a map or a struct. If stmt is not supported,
+// the error is kept to be returned when q is executed.
+func (q *Query) whereClause(stmt interface{}, args []interface{}) (clause, bool) {
+	quoter := q.Connection.Dialect
+	switch s := stmt.(type) {
+	case string:
+		return clause{newSQLScanner(q.Connection.Dialect).expandIn(s, len(args)), args}, true
+	case Eq:
+		return s.clause(quoter), true
+	case map[string]interface{}:
+		return Eq(s).clause(quoter), true
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(stmt))
+	if v.Kind() != reflect.Struct {
+		q.setErr(fmt.Errorf("unsupported where condition %T", stmt))
+		return clause{}, false
+	}
+	eq := Eq{}
+	for name := range columns.ForStruct(stmt, "", "id").Cols {
+		f := fieldMapper.FieldByName(v, name)
+		if f.IsValid() && !IsZeroOfUnderlyingType(f.Interface()) {
+			eq[name] = f.Interface()
+		}
+	}
+	return eq.clause(quoter), true
+}
+
+// clause builds the where clause of eq, quoting the column names with
+// quoter.
+func (eq Eq) clause(quoter quotable) clause {
+	if len(eq) == 0 {
+		return clause{Fragment: "1=1"}
+	}
+	names := make([]string, 0, len(eq))
+	for name := range eq {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cl clause
+	fragments := make([]string, 0, len(names))
+	for _, name := range names {
+		value := eq[name]
+		column := quoteColumn(quoter, name)
+		rv := reflect.ValueOf(value)
+		if value == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+			fragments = append(fragments, fmt.Sprintf("%s IS NULL", column))
+			continue
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			if rv.Len() == 0 {
+				fragments = append(fragments, "1=0")
+				continue
+			}
+			inq := make([]string, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				inq[i] = "?"
+				cl.Arguments = append(cl.Arguments, rv.Index(i).Interface())
+			}
+			fragments = append(fragments, fmt.Sprintf("%s IN (%s)", column, strings.Join(inq, ",")))
+			continue
+		}
+		fragments = append(fragments, fmt.Sprintf("%s = ?", column))
+		cl.Arguments = append(cl.Arguments, value)
+	}
+	cl.Fragment = strings.Join(fragments, " AND ")
+	if len(fragments) > 1 {
+		cl.Fragment = fmt.Sprintf("(%s)", cl.Fragment)
+	}
+	return cl
+}
+
+// quoteColumn quotes name with quoter, quoting the table prefix of the
+// column, if any, separately.
+func quoteColumn(quoter quotable, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoter.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
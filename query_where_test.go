@@ -0,0 +1,148 @@
+package pop
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Where_Or(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	m := NewModel(new(Enemy), context.Background())
+
+	sql, args := PDB.Where("id = ?", 1).Or("name = ?", "Mark").ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE (id = ? OR name = ?)"), sql)
+	r.Equal([]interface{}{1, "Mark"}, args)
+
+	// only the last clause is combined, the earlier ones still apply
+	sql, args = PDB.Where("id = ?", 1).Where("age > ?", 18).Or("name = ?", "Mark").Where("active = ?", true).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE id = ? AND (age > ? OR name = ?) AND active = ?"), sql)
+	r.Equal([]interface{}{1, 18, "Mark", true}, args)
+
+	// clones of the query are not modified
+	base := PDB.Where("id = ?", 1).Where("age > ?", 18)
+	clone := Q(PDB)
+	base.Clone(clone)
+	base.Or("name = ?", "Mark")
+	sql, args = clone.ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE id = ? AND age > ?"), sql)
+	r.Equal([]interface{}{1, 18}, args)
+
+	sql, _ = PDB.Where("id = ? or id = ?", 1, 2).Or("name = ?", "Mark").Or("age > ?", 18).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE (((id = ? or id = ?) OR name = ?) OR age > ?)"), sql)
+
+	sql, args = Q(PDB).Or("name = ?", "Mark").ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE name = ?"), sql)
+	r.Equal([]interface{}{"Mark"}, args)
+}
+
+func Test_Where_Groups(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	m := NewModel(new(Enemy), context.Background())
+
+	q := PDB.Where("published = ?", true).WhereGroup(func(q *Query) {
+		q.Where("author_id = ?", 1).Or("editor_id = ?", 2)
+	})
+	sql, args := q.ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE published = ? AND (author_id = ? OR editor_id = ?)"), sql)
+	r.Equal([]interface{}{true, 1, 2}, args)
+
+	q = PDB.Where("published = ?", true).OrGroup(func(q *Query) {
+		q.Where("author_id = ?", 1).Where("draft = ?", false)
+	})
+	sql, args = q.ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE (published = ? OR (author_id = ? AND draft = ?))"), sql)
+	r.Equal([]interface{}{true, 1, false}, args)
+
+	q = PDB.Where("tenant_id = ?", 3).Where("published = ?", true).OrGroup(func(q *Query) {
+		q.Where("author_id = ?", 1).Where("draft = ?", false)
+	})
+	sql, args = q.ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE tenant_id = ? AND (published = ? OR (author_id = ? AND draft = ?))"), sql)
+	r.Equal([]interface{}{3, true, 1, false}, args)
+
+	sql, _ = PDB.Where("id = ?", 1).WhereGroup(func(q *Query) {}).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE id = ?"), sql)
+
+	sql, args = PDB.Where("id = ?", 1).Not("name = ? OR age > ?", "Mark", 18).ToSQL(m)
+	r.Equal(ts("SELECT enemies.A FROM enemies AS enemies WHERE id = ? AND NOT (name = ? OR age > ?)"), sql)
+	r.Equal([]interface{}{1, "Mark", 18}, args)
+}
+
+func Test_Where_Conditions(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	m := NewModel(new(Enemy), context.Background())
+	q := PDB.Dialect.Quote
+
+	sql, args := PDB.WhereCond(Eq{"status": "active", "deleted_at": nil, "id": []int{1, 2}}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE (%s IS NULL AND %s IN (?,?) AND %s = ?)", q("deleted_at"), q("id"), q("status"))), sql)
+	r.Equal([]interface{}{1, 2, "active"}, args)
+
+	sql, args = PDB.WhereCond(map[string]interface{}{"id": []int{}}).Or(Eq{"status": "active"}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE (1=0 OR %s = ?)", q("status"))), sql)
+	r.Equal([]interface{}{"active"}, args)
+
+	sql, args = Q(PDB).Not(Eq{"id": []int{1, 2, 3}}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE NOT (%s IN (?,?,?))", q("id"))), sql)
+	r.Equal([]interface{}{1, 2, 3}, args)
+
+	sql, args = PDB.WhereCond(&Song{Title: "A", UserID: 5}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE (%s = ? AND %s = ?)", q("title"), q("u_id"))), sql)
+	r.Equal([]interface{}{"A", 5}, args)
+
+	sql, args = PDB.WhereCond(Eq{"enemies.id": 1}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE %s.%s = ?", q("enemies"), q("id"))), sql)
+	r.Equal([]interface{}{1}, args)
+
+	var missing *int
+	sql, args = PDB.WhereCond(Eq{"deleted_at": missing, "id": &[]int{1}[0]}).ToSQL(m)
+	r.Equal(ts(fmt.Sprintf("SELECT enemies.A FROM enemies AS enemies WHERE (%s IS NULL AND %s = ?)", q("deleted_at"), q("id"))), sql)
+	r.Len(args, 1)
+
+	query := PDB.Where("id = ?", 1).WhereCond(42)
+	r.Len(query.whereClauses, 1)
+	_, _, err := query.toSQL(m)
+	r.EqualError(err, "unsupported where condition int")
+
+	_, _, err = PDB.Where("id = ?", 1).WhereGroup(func(q *Query) { q.Or(42) }).toSQL(m)
+	r.EqualError(err, "unsupported where condition int")
+}
+
+func Test_Where_Or_Integration(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+	transaction(func(tx *Connection) {
+		for _, title := range []string{"A", "B", "C"} {
+			r.NoError(tx.Create(nil, &Song{Title: title}))
+		}
+
+		var songs []Song
+		r.NoError(tx.WhereCond(Eq{"title": "A"}).Or("title = ?", "B").Order("title").All(nil, &songs))
+		r.Len(songs, 2)
+		r.Equal("A", songs[0].Title)
+		r.Equal("B", songs[1].Title)
+
+		r.NoError(tx.Q().Not(Eq{"title": []string{"A", "B"}}).All(nil, &songs))
+		r.Len(songs, 1)
+		r.Equal("C", songs[0].Title)
+
+		r.NoError(tx.Where("title != ?", "A").WhereGroup(func(q *Query) {
+			q.Where("title = ?", "A").Or(&Song{Title: "C"})
+		}).All(nil, &songs))
+		r.Len(songs, 1)
+		r.Equal("C", songs[0].Title)
+	})
+}
@@ -0,0 +1,140 @@
+package pop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// replicaSet holds the read replicas of a connection.
+type replicaSet struct {
+	replicas []*replica
+	next     uint32
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type replica struct {
+	conn    *Connection
+	healthy atomic.Bool
+}
+
+// openReplicas opens a connection to every replica URL of the connection
+// details, and starts checking their health in the background.
+func openReplicas(deets *ConnectionDetails, attributes ...attribute.KeyValue) (*replicaSet, error) {
+	rs := &replicaSet{stop: make(chan struct{})}
+	for _, u := range deets.Replicas {
+		c, err := NewConnection(deets.replicaDetails(u))
+		if err != nil {
+			rs.close()
+			return nil, fmt.Errorf("could not create replica connection: %w", err)
+		}
+		if err := c.Open(attributes...); err != nil {
+			rs.close()
+			return nil, fmt.Errorf("could not open replica connection: %w", err)
+		}
+		r := &replica{conn: c}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+	go rs.checkHealth(deets.ReplicaHealthCheckInterval())
+	return rs, nil
+}
+
+// pick returns the next healthy replica in round-robin order, or nil if
+// none of them is healthy.
+func (rs *replicaSet) pick() *replica {
+	n := len(rs.replicas)
+	for i := 0; i < n; i++ {
+		r := rs.replicas[int(atomic.AddUint32(&rs.next, 1)-1)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// checkHealth pings every replica at the given interval, taking the ones
+// which do not answer out of the rotation until they do again.
+func (rs *replicaSet) checkHealth(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-t.C:
+		}
+		for _, r := range rs.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := r.ping(ctx)
+			cancel()
+			if healthy := err == nil; healthy != r.healthy.Swap(healthy) {
+				if healthy {
+					txlog(logging.Info, nil, r.conn, "replica is healthy again")
+				} else {
+					txlog(logging.Warn, nil, r.conn, "replica is unhealthy: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func (r *replica) ping(ctx context.Context) error {
+	p, ok := r.conn.Store.(interface{ PingContext(context.Context) error })
+	if !ok {
+		return nil
+	}
+	return p.PingContext(ctx)
+}
+
+func (rs *replicaSet) close() error {
+	rs.stopOnce.Do(func() { close(rs.stop) })
+	var errs []error
+	for _, r := range rs.replicas {
+		if r.conn.Store == nil {
+			continue
+		}
+		if err := r.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readConnection returns the connection to run the read queries of q on.
+// Outside of a transaction, and unless UsePrimary was called or the query
+// locks rows, it is a copy of the connection talking to one of the healthy
+// read replicas.
+func (q *Query) readConnection() *Connection {
+	c := q.Connection
+	if q.usePrimary || q.rowLock != nil || c.TX != nil || c.replicas == nil {
+		return c
+	}
+	r := c.replicas.pick()
+	if r == nil {
+		return c
+	}
+	cn := c.copy()
+	cn.Store = r.conn.Store
+	if cs, ok := c.Store.(contextStore); ok {
+		cn.Store = contextStore{store: r.conn.Store, ctx: cs.ctx}
+	}
+	return cn
+}
+
+// UsePrimary makes the read queries of q run on the primary database, even if
+// the connection has read replicas. This is useful to read data which was
+// just written, and might not have reached the replicas yet.
+//
+//	c.Q().UsePrimary().Find(&user, id)
+func (q *Query) UsePrimary() *Query {
+	q.usePrimary = true
+	return q
+}
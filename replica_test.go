@@ -0,0 +1,138 @@
+package pop
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseConfig_Replicas(t *testing.T) {
+	r := require.New(t)
+	config := strings.NewReader(`
+postgres:
+  url: "postgres://postgres@primary:5432/pop_test"
+  replicas:
+    - "postgres://postgres@replica1:5432/pop_test"
+    - "postgres://postgres@replica2:5432/pop_test"
+  options:
+    replica_health_check_interval: 2s`)
+	conns, err := ParseConfig(config)
+	r.NoError(err)
+	r.Equal([]string{
+		"postgres://postgres@replica1:5432/pop_test",
+		"postgres://postgres@replica2:5432/pop_test",
+	}, conns["postgres"].Replicas)
+	r.Equal("2s", conns["postgres"].Options["replica_health_check_interval"])
+}
+
+func Test_ReplicaSet_Pick(t *testing.T) {
+	r := require.New(t)
+	a, b, c := &replica{}, &replica{}, &replica{}
+	a.healthy.Store(true)
+	c.healthy.Store(true)
+	rs := &replicaSet{replicas: []*replica{a, b, c}}
+
+	r.Equal(a, rs.pick())
+	r.Equal(c, rs.pick())
+	r.Equal(a, rs.pick())
+
+	a.healthy.Store(false)
+	c.healthy.Store(false)
+	r.Nil(rs.pick())
+}
+
+// closeStore is a store whose Close returns err.
+type closeStore struct {
+	store
+	err    error
+	closed bool
+}
+
+func (s *closeStore) Close() error {
+	s.closed = true
+	return s.err
+}
+
+func Test_Connection_Close_Replicas(t *testing.T) {
+	r := require.New(t)
+
+	replicaErr := errors.New("replica is gone")
+	primary := &closeStore{}
+	failing := &closeStore{err: replicaErr}
+	other := &closeStore{}
+	c := &Connection{
+		Store: primary,
+		replicas: &replicaSet{
+			stop: make(chan struct{}),
+			replicas: []*replica{
+				{conn: &Connection{Store: failing}},
+				{conn: &Connection{Store: other}},
+			},
+		},
+	}
+
+	err := c.Close()
+	r.True(errors.Is(err, replicaErr), "%v", err)
+	r.True(failing.closed)
+	r.True(other.closed)
+	r.True(primary.closed)
+	r.Nil(c.Store)
+	r.Nil(c.replicas)
+}
+
+var errStaleRead = errors.New("read from a stale replica")
+
+// staleStore is a store standing for a replica which has not caught up with
+// the primary: all of its reads fail with errStaleRead.
+type staleStore struct {
+	store
+}
+
+func (staleStore) Get(interface{}, string, ...interface{}) error {
+	return errStaleRead
+}
+
+func (staleStore) GetContext(context.Context, interface{}, string, ...interface{}) error {
+	return errStaleRead
+}
+
+func (staleStore) Select(interface{}, string, ...interface{}) error {
+	return errStaleRead
+}
+
+func (staleStore) SelectContext(context.Context, interface{}, string, ...interface{}) error {
+	return errStaleRead
+}
+
+func Test_Replica_ReadYourWrites(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	c := PDB.copy()
+	c.replicas = &replicaSet{replicas: []*replica{{conn: &Connection{Store: staleStore{}}}}}
+	c.replicas.replicas[0].healthy.Store(true)
+
+	s := &Subscription{Email: "replica@example.com", Plan: "free"}
+	r.NoError(c.Create(nil, s))
+	defer func() { r.NoError(PDB.Destroy(nil, s)) }()
+
+	// finders read from the replica by default
+	r.ErrorIs(c.Find(nil, &Subscription{}, s.ID), errStaleRead)
+
+	s.Plan = ""
+	r.NoError(c.Reload(nil, s))
+	r.Equal("free", s.Plan)
+
+	verrs, err := ValidateUnique(c, &Subscription{Email: s.Email, Plan: "gold"}, "email")
+	r.NoError(err)
+	r.True(verrs.HasAny())
+
+	verrs, err = ValidateExists(c, "subscriptions", s.ID)
+	r.NoError(err)
+	r.False(verrs.HasAny())
+}
@@ -0,0 +1,52 @@
+package pop
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/XSAM/otelsql"
+	"github.com/gofrs/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID. Once the
+// context is attached to a connection with `Connection.WithContext`, the ID is
+// used for logging, dialect calls and tracing without passing it explicitly.
+//
+//	tx := c.WithContext(pop.WithRequestID(ctx, id))
+//	tx.Create(nil, &user) // logged with id
+func WithRequestID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by `WithRequestID`,
+// or nil if there is none.
+func RequestIDFromContext(ctx context.Context) *uuid.UUID {
+	if ctx == nil {
+		return nil
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// requestID returns the given id if it is set, and the request ID carried by
+// the connection's context otherwise.
+func (c *Connection) requestID(id *uuid.UUID) *uuid.UUID {
+	if id != nil {
+		return id
+	}
+	return RequestIDFromContext(c.Context())
+}
+
+// requestIDAttributes adds the request ID of the query context, if any,
+// to the OpenTelemetry span of the query.
+func requestIDAttributes(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) []attribute.KeyValue {
+	if id := RequestIDFromContext(ctx); id != nil {
+		return []attribute.KeyValue{attribute.String("pop.request_id", id.String())}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package pop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestIDFromContext(t *testing.T) {
+	r := require.New(t)
+
+	r.Nil(RequestIDFromContext(context.Background()))
+
+	id := uuid.Must(uuid.NewV4())
+	got := RequestIDFromContext(WithRequestID(context.Background(), id))
+	r.NotNil(got)
+	r.Equal(id, *got)
+}
+
+func Test_Connection_requestID(t *testing.T) {
+	r := require.New(t)
+
+	c := &Connection{}
+	r.Nil(c.requestID(nil))
+
+	id := uuid.Must(uuid.NewV4())
+	c = c.WithContext(WithRequestID(context.Background(), id))
+	r.Equal(id, *c.requestID(nil))
+
+	explicit := uuid.Must(uuid.NewV4())
+	r.Equal(explicit, *c.requestID(&explicit))
+}
+
+func Test_Connection_WithContext_KeepsEager(t *testing.T) {
+	r := require.New(t)
+
+	c := (&Connection{}).Eager("Books")
+	cn := c.WithContext(context.Background())
+	r.True(cn.eager)
+	r.Equal([]string{"Books"}, cn.eagerFields)
+}
+
+func Test_RequestID_Logging(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	oldTxLog := txlog
+	defer func() { txlog = oldTxLog }()
+
+	var ids []*uuid.UUID
+	SetTxLogger(func(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{}) {
+		if lvl == logging.SQL {
+			ids = append(ids, requestID)
+		}
+	})
+
+	id := uuid.Must(uuid.NewV4())
+	ctx := WithRequestID(context.Background(), id)
+	transaction(func(tx *Connection) {
+		user := User{Name: nulls.NewString("Mark")}
+		r.NoError(tx.CreateContext(ctx, &user))
+		r.NoError(tx.FindContext(ctx, &User{}, user.ID))
+		r.NoError(tx.Where("id = ?", user.ID).AllContext(ctx, &Users{}))
+	})
+
+	var matched int
+	for _, rid := range ids {
+		if rid != nil && *rid == id {
+			matched++
+		}
+	}
+	r.GreaterOrEqual(matched, 3)
+}
@@ -25,6 +25,7 @@ func newSchemaMigrations(name string) fizz.Table {
 			{Name: fmt.Sprintf("%s_version_idx", name), Columns: []string{"version"}, Unique: true},
 		},
 	}
+	tab.Columns = append(tab.Columns, schemaMigrationsDetailColumns...)
 	// this is for https://github.com/gobuffalo/pop/issues/659.
 	// primary key is not necessary for the migration table but it looks like
 	// some database engine versions requires it for index.
@@ -5,7 +5,7 @@ package pop
 import "github.com/gobuffalo/fizz"
 
 func newSchemaMigrations(name string) fizz.Table {
-	return fizz.Table{
+	tab := fizz.Table{
 		Name: name,
 		Columns: []fizz.Column{
 			{
@@ -18,4 +18,6 @@ func newSchemaMigrations(name string) fizz.Table {
 		},
 		Indexes: []fizz.Index{},
 	}
+	tab.Columns = append(tab.Columns, schemaMigrationsDetailColumns...)
+	return tab
 }
@@ -11,11 +11,11 @@ type ScopeFunc func(q *Query) *Query
 //		}
 //	}
 //
-//	func WithDeleted(q *pop.Query) *pop.Query {
-//		return q.Where("deleted_at is null")
+//	func Active(q *pop.Query) *pop.Query {
+//		return q.Where("active = ?", true)
 //	}
 //
-//	c.Scope(ByName("mark)).Scope(WithDeleted).First(&User{})
+//	c.Scope(ByName("mark)).Scope(Active).First(&User{})
 func (q *Query) Scope(sf ScopeFunc) *Query {
 	return sf(q)
 }
@@ -28,11 +28,11 @@ func (q *Query) Scope(sf ScopeFunc) *Query {
 //		}
 //	}
 //
-//	func WithDeleted(q *pop.Query) *pop.Query {
-//		return q.Where("deleted_at is null")
+//	func Active(q *pop.Query) *pop.Query {
+//		return q.Where("active = ?", true)
 //	}
 //
-//	c.Scope(ByName("mark)).Scope(WithDeleted).First(&User{})
+//	c.Scope(ByName("mark)).Scope(Active).First(&User{})
 func (c *Connection) Scope(sf ScopeFunc) *Query {
 	return Q(c).Scope(sf)
 }
@@ -0,0 +1,116 @@
+package pop
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gofrs/uuid"
+)
+
+// logSlowQuery logs the given statement at warn level if it took longer than
+// the slow query threshold of the connection, whether it failed or not. When
+// enabled, the query plan of successful SELECT statements is attached to the
+// log entry. c must be the connection the statement ran on, and no rows of
+// the statement must be open anymore, since the plan is queried on c.
+func (c *Connection) logSlowQuery(requestID *uuid.UUID, table string, stmt string, args []interface{}, elapsed time.Duration, err error) {
+	if c.Dialect == nil {
+		return
+	}
+	deets := c.Dialect.Details()
+	threshold := deets.SlowQueryThreshold()
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	plan := ""
+	if err == nil && deets.SlowQueryExplain() && isSelect(stmt) {
+		if d, ok := c.Dialect.(explainable); ok {
+			var xerr error
+			plan, xerr = d.Explain(c, stmt, args...)
+			if xerr != nil {
+				plan = fmt.Sprintf("could not explain query: %v", xerr)
+			}
+		}
+	}
+
+	if slogger == nil {
+		s := "slow query (%s > %s): %s | %v"
+		xargs := []interface{}{elapsed, threshold, stmt, args}
+		if err != nil {
+			s += " | error: %v"
+			xargs = append(xargs, err)
+		}
+		if plan != "" {
+			s += "\n%s"
+			xargs = append(xargs, plan)
+		}
+		txlog(logging.Warn, requestID, c, s, xargs...)
+		return
+	}
+
+	attrs := append(slogAttrs(requestID, c),
+		slog.String("sql", stmt),
+		slog.Any("args", args),
+		slog.Duration("duration", elapsed),
+		slog.Duration("threshold", threshold),
+	)
+	if table != "" {
+		attrs = append(attrs, slog.String("table", table))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if plan != "" {
+		attrs = append(attrs, slog.String("plan", plan))
+	}
+	slogger.LogAttrs(context.Background(), logging.Warn.SlogLevel(), "slow query", attrs...)
+}
+
+func isSelect(stmt string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT")
+}
+
+// genericExplain runs the given SELECT statement prefixed by the EXPLAIN
+// statement of the dialect, and returns the resulting plan one row per line.
+func genericExplain(c *Connection, explain string, stmt string, args ...interface{}) (string, error) {
+	query := explain + " " + stmt
+	txlog(logging.SQL, c.requestID(nil), c, query, args...)
+	rows, err := c.Store.QueryxContext(c.Context(), query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return "", err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			if len(values) == 1 {
+				fields[i] = fmt.Sprintf("%v", v)
+			} else {
+				fields[i] = fmt.Sprintf("%s=%v", cols[i], v)
+			}
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
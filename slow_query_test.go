@@ -0,0 +1,92 @@
+package pop
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConnectionDetails_SlowQueryOptions(t *testing.T) {
+	r := require.New(t)
+	cd := &ConnectionDetails{
+		Dialect: "postgres",
+		Options: map[string]string{
+			"slow_query_threshold": "250ms",
+			"slow_query_explain":   "true",
+			"sslmode":              "require",
+		},
+	}
+
+	r.Equal(250*time.Millisecond, cd.SlowQueryThreshold())
+	r.True(cd.SlowQueryExplain())
+	r.Equal("sslmode=require", cd.OptionsString(""))
+
+	cd = &ConnectionDetails{}
+	r.Zero(cd.SlowQueryThreshold())
+	r.False(cd.SlowQueryExplain())
+}
+
+func Test_SlowQuery_Logging(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	deets := PDB.Dialect.Details()
+	deets.setOption("slow_query_threshold", "1ns")
+	deets.setOption("slow_query_explain", "true")
+	oldTxLog := txlog
+	defer func() {
+		txlog = oldTxLog
+		deets.setOption("slow_query_threshold", "")
+		deets.setOption("slow_query_explain", "")
+	}()
+
+	var warnings []string
+	SetTxLogger(func(lvl logging.Level, requestID *uuid.UUID, anon interface{}, s string, args ...interface{}) {
+		if lvl == logging.Warn {
+			warnings = append(warnings, fmt.Sprintf(s, args...))
+		}
+	})
+
+	var failed, each []string
+	transaction(func(tx *Connection) {
+		user := User{Name: nulls.NewString("Mark")}
+		r.NoError(tx.Create(nil, &user))
+
+		warnings = nil
+		r.NoError(tx.Where("id = ?", user.ID).First(nil, &User{}))
+
+		found := warnings
+		warnings = nil
+		r.Error(tx.RawQuery("SELECT * FROM unknown_table").All(nil, &[]User{}))
+
+		failed = warnings
+		warnings = nil
+		r.NoError(tx.Where("id = ?", user.ID).Each(nil, &User{}, func(interface{}) error {
+			return nil
+		}))
+		each = warnings
+		warnings = found
+	})
+
+	r.Len(warnings, 1)
+	r.Contains(warnings[0], "slow query")
+	r.Contains(warnings[0], "SELECT")
+	r.Contains(warnings[0], "\n")
+
+	// failing statements are reported too, without a plan
+	r.Len(failed, 1)
+	r.Contains(failed[0], "unknown_table")
+	r.Contains(failed[0], "error:")
+	r.NotContains(failed[0], "\n")
+
+	// the plan of Each is queried once its rows are closed
+	r.Len(each, 1)
+	r.Contains(each[0], "\n")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 var migrationPath string
+var migrationDryRun bool
 
 var migrateCmd = &cobra.Command{
 	Use:     "migrate",
@@ -26,6 +27,7 @@ var migrateCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		mig.DryRun = migrationDryRun
 		return mig.Up()
 	},
 }
@@ -33,4 +35,5 @@ var migrateCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(migrateCmd)
 	RootCmd.PersistentFlags().StringVarP(&migrationPath, "path", "p", "./migrations", "Path to the migrations folder")
+	migrateCmd.Flags().BoolVar(&migrationDryRun, "dry-run", false, "Print the SQL of the pending migrations without running them.")
 }
@@ -15,6 +15,7 @@ var migrateUpCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		mig.DryRun = migrationDryRun
 		_, err = mig.UpTo(migrationStepUp)
 		return err
 	},
@@ -23,4 +24,5 @@ var migrateUpCmd = &cobra.Command{
 func init() {
 	migrateCmd.AddCommand(migrateUpCmd)
 	migrateUpCmd.Flags().IntVarP(&migrationStepUp, "step", "s", 0, "Number of migrations to apply. Use 0 to apply all pending.")
+	migrateUpCmd.Flags().BoolVar(&migrationDryRun, "dry-run", false, "Print the SQL of the pending migrations without running them.")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Accefy/pop"
+	"github.com/spf13/cobra"
+)
+
+var migrateVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks the applied migrations against the migration files.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mig, err := pop.NewFileMigrator(migrationPath, getConn())
+		if err != nil {
+			return err
+		}
+		drift, err := mig.Verify()
+		if err != nil {
+			return err
+		}
+		if !drift.HasAny() && len(drift.Unverified) == 0 {
+			fmt.Println("All applied migrations match the migration files.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+		_, _ = fmt.Fprintln(w, "Version\tName\tProblem\t")
+		for _, mf := range drift.Modified {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t\n", mf.Version, mf.Name, "Modified since applied")
+		}
+		for _, mf := range drift.Missing {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t\n", mf.Version, mf.Name, "Not applied")
+		}
+		for _, am := range drift.Unknown {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t\n", am.Version, am.Name.String, "Applied without migration file")
+		}
+		for _, am := range drift.Unverified {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t\n", am.Version, am.Name.String, "Checksum not recorded")
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if !drift.HasAny() {
+			return nil
+		}
+		return errors.New("migrations have drifted from the database")
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateVerifyCmd)
+}
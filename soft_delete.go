@@ -0,0 +1,192 @@
+package pop
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Accefy/pop/columns"
+	"github.com/gofrs/uuid"
+)
+
+// SoftDeletable interface allows for the customization of the column used
+// to soft delete a model. Models with a `DeletedAt` field are soft deleted
+// using its column, without having to implement `SoftDeletable`.
+//
+// The column must be nullable and mapped to a field of type `time.Time`,
+// `*time.Time` or a nullable time such as `nulls.Time`.
+type SoftDeletable interface {
+	DeletedAtColumn() string
+}
+
+type deletedScope int
+
+const (
+	// excludeDeleted filters soft deleted records out, this is the default.
+	excludeDeleted deletedScope = iota
+	// withDeleted returns all the records.
+	withDeleted
+	// onlyDeleted returns soft deleted records only.
+	onlyDeleted
+)
+
+// WithDeleted includes soft deleted records in the results of the query.
+//
+//	c.WithDeleted().All(&users)
+func (c *Connection) WithDeleted() *Query {
+	return Q(c).WithDeleted()
+}
+
+// WithDeleted includes soft deleted records in the results of the query.
+//
+//	q.WithDeleted().All(&users)
+func (q *Query) WithDeleted() *Query {
+	q.deletedScope = withDeleted
+	return q
+}
+
+// OnlyDeleted restricts the results of the query to soft deleted records.
+//
+//	c.OnlyDeleted().All(&users)
+func (c *Connection) OnlyDeleted() *Query {
+	return Q(c).OnlyDeleted()
+}
+
+// OnlyDeleted restricts the results of the query to soft deleted records.
+//
+//	q.OnlyDeleted().All(&users)
+func (q *Query) OnlyDeleted() *Query {
+	q.deletedScope = onlyDeleted
+	return q
+}
+
+// softDeleteClause returns the where clause filtering records according to
+// the deleted scope of the query, or nil if the model is not soft deletable.
+func (sq *sqlBuilder) softDeleteClause() *clause {
+	// records selected from a sub-query are filtered by the sub-query
+	if sq.Query.deletedScope == withDeleted || sq.Query.fromQuery != nil {
+		return nil
+	}
+	col := sq.Model.deletedAtColumn()
+	if col == "" {
+		return nil
+	}
+	cond := "IS NULL"
+	if sq.Query.deletedScope == onlyDeleted {
+		cond = "IS NOT NULL"
+	}
+	return &clause{Fragment: fmt.Sprintf("%s.%s %s", sq.Model.Alias(), col, cond)}
+}
+
+// deletedAtColumn returns the column used to soft delete the model, or an
+// empty string if the model is not soft deletable.
+func (m *Model) deletedAtColumn() string {
+	t := reflect.TypeOf(m.Value)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	if sd, ok := reflect.New(t).Interface().(SoftDeletable); ok {
+		return sd.DeletedAtColumn()
+	}
+
+	field, ok := t.FieldByName("DeletedAt")
+	if !ok {
+		return ""
+	}
+	tag := columns.TagsFor(field).Find("db")
+	if tag.Ignored() {
+		return ""
+	}
+	return tag.Value
+}
+
+// setDeletedAt sets the field mapped to the soft delete column to t, or
+// clears it if t is nil.
+func (m *Model) setDeletedAt(column string, t *time.Time) error {
+	fbn := fieldMapper.FieldByName(reflect.Indirect(reflect.ValueOf(m.Value)), column)
+	if !fbn.IsValid() {
+		return fmt.Errorf("model %T does not have a field for column %s", m.Value, column)
+	}
+	if t == nil {
+		fbn.Set(reflect.Zero(fbn.Type()))
+		return nil
+	}
+
+	switch {
+	case fbn.Type() == reflect.TypeOf(*t):
+		fbn.Set(reflect.ValueOf(*t))
+	case fbn.Type() == reflect.TypeOf(t):
+		fbn.Set(reflect.ValueOf(t))
+	default:
+		s, ok := fbn.Addr().Interface().(sql.Scanner)
+		if !ok {
+			return fmt.Errorf("could not set column %s of model %T: unsupported type %s", column, m.Value, fbn.Type())
+		}
+		return s.Scan(*t)
+	}
+	return nil
+}
+
+// updateDeletedAt sets the soft delete column of the model to t and saves
+// it, without touching the other columns.
+func (c *Connection) updateDeletedAt(requestID *uuid.UUID, m *Model, column string, t *time.Time) error {
+	if err := m.setDeletedAt(column, t); err != nil {
+		return err
+	}
+	cols := columns.NewColumnsWithAlias(m.TableName(), m.As, m.IDField())
+	cols.Add(column)
+	if err := c.Dialect.Update(c, requestID, m, cols); err != nil {
+		return c.translateError(m.TableName(), err)
+	}
+	m.snapshotColumns(cols)
+	return nil
+}
+
+// HardDestroy deletes a given entry from the database, even if the model is
+// soft deletable.
+//
+// If model is a slice, each item of the slice is deleted from the database.
+func (c *Connection) HardDestroy(requestID *uuid.UUID, model interface{}) error {
+	requestID = c.requestID(requestID)
+	sm := NewModel(model, c.Context())
+	return sm.iterate(func(m *Model) error {
+		return c.timeFunc("HardDestroy", func() error {
+			var err error
+
+			if err = m.beforeDestroy(c); err != nil {
+				return err
+			}
+			if err = c.Dialect.Destroy(c, requestID, m); err != nil {
+				return c.translateError(m.TableName(), err)
+			}
+
+			return m.afterDestroy(c)
+		})
+	})
+}
+
+// Restore brings back a soft deleted entry, by clearing its soft delete
+// column.
+//
+// If model is a slice, each item of the slice is restored.
+func (c *Connection) Restore(requestID *uuid.UUID, model interface{}) error {
+	requestID = c.requestID(requestID)
+	sm := NewModel(model, c.Context())
+	column := sm.deletedAtColumn()
+	if column == "" {
+		return fmt.Errorf("model %T is not soft deletable", model)
+	}
+	return sm.iterate(func(m *Model) error {
+		return c.timeFunc("Restore", func() error {
+			return c.updateDeletedAt(requestID, m, column, nil)
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Model_deletedAtColumn(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("deleted_at", NewModel(&Note{}, nil).deletedAtColumn())
+	r.Equal("deleted_at", NewModel(&Notes{}, nil).deletedAtColumn())
+	r.Equal("archived_at", NewModel(&[]ArchivableNote{}, nil).deletedAtColumn())
+	r.Equal("", NewModel(&User{}, nil).deletedAtColumn())
+	r.Equal("", NewModel("notes", nil).deletedAtColumn())
+}
+
+func Test_SoftDelete(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		n1 := &Note{Body: "first"}
+		n2 := &Note{Body: "second"}
+		r.NoError(tx.Create(nil, n1))
+		r.NoError(tx.Create(nil, n2))
+
+		r.NoError(tx.Destroy(nil, n1))
+		r.True(n1.DeletedAt.Valid)
+
+		r.Error(tx.Find(nil, &Note{}, n1.ID))
+		ct, err := tx.Count(nil, &Note{})
+		r.NoError(err)
+		r.Equal(1, ct)
+
+		notes := Notes{}
+		r.NoError(tx.All(nil, &notes))
+		r.Len(notes, 1)
+		r.Equal(n2.ID, notes[0].ID)
+
+		notes = Notes{}
+		r.NoError(tx.WithDeleted().Order("id asc").All(nil, &notes))
+		r.Len(notes, 2)
+
+		notes = Notes{}
+		r.NoError(tx.OnlyDeleted().All(nil, &notes))
+		r.Len(notes, 1)
+		r.Equal(n1.ID, notes[0].ID)
+		r.True(notes[0].DeletedAt.Valid)
+
+		r.NoError(tx.Restore(nil, n1))
+		r.False(n1.DeletedAt.Valid)
+		r.NoError(tx.Find(nil, &Note{}, n1.ID))
+
+		r.NoError(tx.HardDestroy(nil, n1))
+		ct, err = tx.WithDeleted().Count(nil, &Note{})
+		r.NoError(err)
+		r.Equal(1, ct)
+
+		r.Error(tx.Restore(nil, &User{}))
+	})
+}
+
+func Test_SoftDelete_Custom_Column(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		n := &ArchivableNote{Body: "archived"}
+		r.NoError(tx.Create(nil, n))
+		r.NoError(tx.Destroy(nil, n))
+		r.NotNil(n.ArchivedAt)
+
+		r.Error(tx.Find(nil, &ArchivableNote{}, n.ID))
+		r.NoError(tx.OnlyDeleted().Find(nil, &ArchivableNote{}, n.ID))
+
+		r.NoError(tx.Restore(nil, n))
+		r.Nil(n.ArchivedAt)
+		r.NoError(tx.Find(nil, &ArchivableNote{}, n.ID))
+	})
+}
+
+func Test_SoftDelete_Eager(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		nb := &Notebook{Name: "todo"}
+		r.NoError(tx.Create(nil, nb))
+
+		n1 := &Note{NotebookID: nulls.NewInt(nb.ID), Body: "kept"}
+		n2 := &Note{NotebookID: nulls.NewInt(nb.ID), Body: "deleted"}
+		r.NoError(tx.Create(nil, n1))
+		r.NoError(tx.Create(nil, n2))
+		r.NoError(tx.Destroy(nil, n2))
+
+		nb = &Notebook{}
+		r.NoError(tx.Eager().Find(nil, nb, n1.NotebookID.Int))
+		r.Len(nb.Notes, 1)
+		r.Equal("kept", nb.Notes[0].Body)
+
+		nb = &Notebook{}
+		r.NoError(tx.EagerPreload().Find(nil, nb, n1.NotebookID.Int))
+		r.Len(nb.Notes, 1)
+		r.Equal("kept", nb.Notes[0].Body)
+	})
+}
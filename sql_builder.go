@@ -8,7 +8,6 @@ import (
 
 	"github.com/Accefy/pop/columns"
 	"github.com/Accefy/pop/logging"
-	"github.com/jmoiron/sqlx"
 )
 
 type sqlBuilder struct {
@@ -69,66 +68,89 @@ func (sq *sqlBuilder) Args() []interface{} {
 	return sq.args
 }
 
-var inRegex = regexp.MustCompile(`(?i)in\s*\(\s*\?\s*\)`)
+func (sq *sqlBuilder) Err() error {
+	if !sq.isCompiled {
+		sq.compile()
+	}
+	return sq.err
+}
 
 func (sq *sqlBuilder) compile() {
 	if sq.sql == "" {
-		if sq.Query.RawSQL.Fragment != "" {
-			if sq.Query.Paginator != nil && !hasLimitOrOffset(sq.Query.RawSQL.Fragment) {
-				sq.sql = sq.buildPaginationClauses(sq.Query.RawSQL.Fragment)
-			} else {
-				if sq.Query.Paginator != nil {
-					log(logging.Warn, nil, "Query already contains pagination")
-				}
-				sq.sql = sq.Query.RawSQL.Fragment
-			}
-			sq.args = sq.Query.RawSQL.Arguments
-		} else {
-			if sq.Model == nil {
-				sq.err = fmt.Errorf("sqlBuilder.compile() called but no RawSQL and Model specified")
-				return
-			}
-			switch sq.Query.Operation {
-			case Select:
-				sq.sql = sq.buildSelectSQL()
-			case Delete:
-				sq.sql = sq.buildDeleteSQL()
-			default:
-				panic("unexpected query operation " + sq.Query.Operation)
-			}
+		sq.build()
+		if sq.err != nil {
+			return
 		}
+		sq.sql, sq.args, sq.err = newSQLScanner(sq.Query.Connection.Dialect).bind(sq.sql, sq.args)
+		sq.sql = sq.Query.Connection.Dialect.TranslateSQL(sq.sql)
+	}
+	sq.isCompiled = true
+}
 
-		if inRegex.MatchString(sq.sql) {
-			s, args, err := sqlx.In(sq.sql, sq.Args()...)
-			if err == nil {
-				sq.sql = s
-				sq.args = args
+// build generates the SQL and arguments of the query, before the arguments
+// are bound and the SQL is translated for the dialect. Sub-queries are built
+// this way to be part of the query using them.
+func (sq *sqlBuilder) build() {
+	if sq.Query.err != nil {
+		sq.err = sq.Query.err
+		return
+	}
+	if sq.Query.RawSQL.Fragment != "" {
+		if sq.Query.Paginator != nil && !hasLimitOrOffset(sq.Query.RawSQL.Fragment) {
+			sq.sql = sq.buildPaginationClauses(sq.Query.RawSQL.Fragment)
+		} else {
+			if sq.Query.Paginator != nil {
+				log(logging.Warn, nil, "Query already contains pagination")
 			}
+			sq.sql = sq.Query.RawSQL.Fragment
 		}
-		sq.sql = sq.Query.Connection.Dialect.TranslateSQL(sq.sql)
+		sq.args = sq.Query.RawSQL.Arguments
+		return
+	}
+	if sq.Model == nil {
+		sq.err = fmt.Errorf("sqlBuilder.compile() called but no RawSQL and Model specified")
+		return
+	}
+	if sq.Query.fromQuery != nil {
+		m := *sq.Model
+		m.As = sq.Query.fromQuery.As
+		sq.Model = &m
+	}
+	switch sq.Query.Operation {
+	case Select:
+		sq.sql = sq.buildSelectSQL()
+	case Delete:
+		sq.sql = sq.buildDeleteSQL()
+	default:
+		panic("unexpected query operation " + sq.Query.Operation)
 	}
 }
 
 func (sq *sqlBuilder) buildSelectSQL() string {
 	cols := sq.buildColumns()
 
+	sql := sq.buildWithClauses()
+
 	fc := sq.buildfromClauses()
 
-	sql := fmt.Sprintf("SELECT %s FROM %s", cols.Readable().SelectString(), fc)
+	sql += fmt.Sprintf("SELECT %s FROM %s", cols.Readable().SelectString(), fc)
 
 	sql = sq.buildJoinClauses(sql)
 	sql = sq.buildWhereClauses(sql)
 	sql = sq.buildGroupClauses(sql)
 	sql = sq.buildOrderClauses(sql)
 	sql = sq.buildPaginationClauses(sql)
+	sql = sq.buildLockClauses(sql)
 
 	return sql
 }
 
 func (sq *sqlBuilder) buildDeleteSQL() string {
+	sql := sq.buildWithClauses()
+
 	fc := sq.buildfromClauses()
 
-	sql := fmt.Sprintf("DELETE FROM %s", fc)
+	sql += fmt.Sprintf("DELETE FROM %s", fc)
 
 	sql = sq.buildWhereClauses(sql)
 
@@ -164,9 +186,23 @@ func (sq *sqlBuilder) buildfromClauses() fromClauses {
 		})
 	}
 
+	if fq := sq.Query.fromQuery; fq != nil {
+		fc[len(sq.Query.fromClauses)].From = fq.Fragment
+		sq.args = append(sq.args, fq.Arguments...)
+	}
+
 	return fc
 }
 
+func (sq *sqlBuilder) buildWithClauses() string {
+	wc := sq.Query.withClauses
+	if len(wc) == 0 {
+		return ""
+	}
+	sq.args = append(sq.args, wc.Args()...)
+	return fmt.Sprintf("WITH %s ", wc.Join(", "))
+}
+
 func (sq *sqlBuilder) buildWhereClauses(sql string) string {
 	mcs := sq.Query.belongsToThroughClauses
 	for _, mc := range mcs {
@@ -175,6 +211,9 @@ func (sq *sqlBuilder) buildWhereClauses(sql string) string {
 	}
 
 	wc := sq.Query.whereClauses
+	if sdc := sq.softDeleteClause(); sdc != nil {
+		wc = append(wc[:len(wc):len(wc)], *sdc)
+	}
 	if len(wc) > 0 {
 		sql = fmt.Sprintf("%s WHERE %s", sql, wc.Join(" AND "))
 		sq.args = append(sq.args, wc.Args()...)
@@ -239,6 +278,17 @@ func (sq *sqlBuilder) buildPaginationClauses(sql string) string {
 	return sql
 }
 
+func (sq *sqlBuilder) buildLockClauses(sql string) string {
+	if sq.Query.rowLock == nil {
+		return sql
+	}
+	d, ok := sq.Query.Connection.Dialect.(lockable)
+	if !ok {
+		return sql
+	}
+	return fmt.Sprintf("%s %s", sql, d.LockClause(*sq.Query.rowLock))
+}
+
 // columnCache is used to prevent columns rebuilding.
 var columnCache = map[string]columns.Columns{}
 var columnCacheMutex = sync.RWMutex{}
@@ -27,6 +27,7 @@ type store interface {
 	NamedExecContext(context.Context, string, interface{}) (sql.Result, error)
 	NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error)
 	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryxContext(context.Context, string, ...interface{}) (*sqlx.Rows, error)
 	PrepareNamedContext(context.Context, string) (*sqlx.NamedStmt, error)
 	TransactionContext(context.Context) (*Tx, error)
 	TransactionContextOptions(context.Context, *sql.TxOptions) (*Tx, error)
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -18,6 +20,9 @@ func init() {
 type Tx struct {
 	ID int
 	*sqlx.Tx
+	savepoints int64
+	hooks      *txHooks
+	hooksLock  sync.Mutex
 }
 
 func newTX(ctx context.Context, db *dB, opts *sql.TxOptions) (*Tx, error) {
@@ -50,6 +55,11 @@ func (tx *Tx) Transaction() (*Tx, error) {
 	return tx, nil
 }
 
+// nextSavepoint returns a new savepoint name, unique within the transaction.
+func (tx *Tx) nextSavepoint() string {
+	return fmt.Sprintf("pop_savepoint_%d", atomic.AddInt64(&tx.savepoints, 1))
+}
+
 // Close does nothing. This is defined so it implements the `Store` interface.
 func (tx *Tx) Close() error {
 	return nil
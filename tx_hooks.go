@@ -0,0 +1,99 @@
+package pop
+
+// txHooks holds the functions to call once the outcome of a transaction, or
+// of a savepoint within it, is known.
+type txHooks struct {
+	afterCommit   []func()
+	afterRollback []func()
+	parent        *txHooks
+}
+
+// AfterCommit registers fn to be called once the transaction of the connection
+// has been committed by `Connection.Transaction`. Within a nested transaction,
+// fn is called when the outermost transaction commits, and discarded if the
+// nested transaction rolls back.
+//
+// If the connection is not in a transaction, fn is called immediately.
+//
+//	c.Transaction(nil, func(tx *pop.Connection) error {
+//		tx.AfterCommit(func() { publish(event) })
+//		return tx.Create(nil, &user)
+//	})
+func (c *Connection) AfterCommit(fn func()) {
+	if c.TX == nil {
+		fn()
+		return
+	}
+	c.TX.hooksLock.Lock()
+	defer c.TX.hooksLock.Unlock()
+	h := c.TX.currentHooks()
+	h.afterCommit = append(h.afterCommit, fn)
+}
+
+// AfterRollback registers fn to be called once the transaction of the
+// connection has been rolled back by `Connection.Transaction`. Within a nested
+// transaction, fn is called as soon as the nested transaction rolls back, or
+// when the outermost transaction does.
+//
+// If the connection is not in a transaction, fn is never called.
+func (c *Connection) AfterRollback(fn func()) {
+	if c.TX == nil {
+		return
+	}
+	c.TX.hooksLock.Lock()
+	defer c.TX.hooksLock.Unlock()
+	h := c.TX.currentHooks()
+	h.afterRollback = append(h.afterRollback, fn)
+}
+
+// currentHooks returns the hooks of the innermost savepoint, or of the
+// transaction itself. The caller must hold hooksLock.
+func (tx *Tx) currentHooks() *txHooks {
+	if tx.hooks == nil {
+		tx.hooks = &txHooks{}
+	}
+	return tx.hooks
+}
+
+// pushHooks starts a new hooks scope for a savepoint.
+func (tx *Tx) pushHooks() {
+	tx.hooksLock.Lock()
+	defer tx.hooksLock.Unlock()
+	tx.hooks = &txHooks{parent: tx.currentHooks()}
+}
+
+// popHooks ends the hooks scope of a savepoint. If the savepoint has been
+// released, its hooks are handed over to the enclosing scope. Otherwise its
+// rollback hooks are run.
+func (tx *Tx) popHooks(committed bool) {
+	tx.hooksLock.Lock()
+	h := tx.currentHooks()
+	tx.hooks = h.parent
+	if committed {
+		tx.hooks.afterCommit = append(tx.hooks.afterCommit, h.afterCommit...)
+		tx.hooks.afterRollback = append(tx.hooks.afterRollback, h.afterRollback...)
+		tx.hooksLock.Unlock()
+		return
+	}
+	tx.hooksLock.Unlock()
+	runHooks(h.afterRollback)
+}
+
+// runHooks runs the hooks of the transaction depending on its outcome.
+func (tx *Tx) runHooks(committed bool) {
+	tx.hooksLock.Lock()
+	h := tx.currentHooks()
+	tx.hooks = nil
+	tx.hooksLock.Unlock()
+	if committed {
+		runHooks(h.afterCommit)
+	} else {
+		runHooks(h.afterRollback)
+	}
+}
+
+func runHooks(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
@@ -0,0 +1,82 @@
+package pop
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Accefy/pop/logging"
+	"github.com/gofrs/uuid"
+)
+
+// retryTransaction calls run, and calls it again as long as it fails with an
+// error the dialect considers retryable, up to the number of attempts set by
+// the "tx_retry_attempts" option.
+func (c *Connection) retryTransaction(requestID *uuid.UUID, run func() error) error {
+	attempts := c.Dialect.Details().TxRetryAttempts()
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if attempt >= attempts || !c.isRetryable(err) {
+			return err
+		}
+		if !c.waitForRetry(requestID, attempt, attempts, err) {
+			return err
+		}
+	}
+}
+
+// restartTransaction runs fn within the restart savepoint sp of the current
+// transaction. As long as fn, or the release of the savepoint, fails with a
+// retryable error, the transaction is rolled back to the savepoint and fn is
+// run again, without giving up the transaction itself.
+func (c *Connection) restartTransaction(requestID *uuid.UUID, sp string, fn func(tx *Connection) error) error {
+	if _, err := genericExec(c, requestID, "", "SAVEPOINT "+sp); err != nil {
+		return fmt.Errorf("could not create restart savepoint: %w", err)
+	}
+
+	attempts := c.Dialect.Details().TxRetryAttempts()
+	for attempt := 1; ; attempt++ {
+		c.TX.pushHooks()
+		err := fn(c)
+		if err == nil {
+			_, err = genericExec(c, requestID, "", "RELEASE SAVEPOINT "+sp)
+		}
+		c.TX.popHooks(err == nil)
+
+		if err == nil || attempt >= attempts || !c.isRetryable(err) {
+			return err
+		}
+		if _, dberr := genericExec(c, requestID, "", "ROLLBACK TO SAVEPOINT "+sp); dberr != nil {
+			return fmt.Errorf("could not roll back to restart savepoint: %w", dberr)
+		}
+		if !c.waitForRetry(requestID, attempt, attempts, err) {
+			return err
+		}
+	}
+}
+
+// isRetryable returns true if the dialect of the connection considers err
+// to be a transient transaction error.
+func (c *Connection) isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	d, ok := c.Dialect.(retryable)
+	return ok && d.IsRetryable(err)
+}
+
+// waitForRetry waits before the next attempt of a transaction, doubling the
+// backoff after every attempt. It returns false if the context of the
+// connection is done in the meantime.
+func (c *Connection) waitForRetry(requestID *uuid.UUID, attempt int, attempts int, err error) bool {
+	backoff := c.Dialect.Details().TxRetryBackoff() << min(attempt-1, 16)
+	txlog(logging.Warn, requestID, c, "retrying transaction (attempt %d of %d) in %s: %v", attempt+1, attempts, backoff, err)
+
+	t := time.NewTimer(backoff)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-c.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package pop
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConnectionDetails_TxRetryOptions(t *testing.T) {
+	r := require.New(t)
+	cd := &ConnectionDetails{
+		Options: map[string]string{
+			"tx_retry_attempts": "5",
+			"tx_retry_backoff":  "2ms",
+			"sslmode":           "disable",
+		},
+	}
+	r.Equal(5, cd.TxRetryAttempts())
+	r.Equal(2*time.Millisecond, cd.TxRetryBackoff())
+	r.Equal("sslmode=disable", cd.OptionsString(""))
+
+	cd = &ConnectionDetails{}
+	r.Equal(1, cd.TxRetryAttempts())
+	r.Equal(10*time.Millisecond, cd.TxRetryBackoff())
+}
+
+func Test_IsSerializationFailure(t *testing.T) {
+	r := require.New(t)
+	r.True(isSerializationFailure(&pgconn.PgError{Code: "40001"}))
+	r.True(isSerializationFailure(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"})))
+	r.False(isSerializationFailure(&pgconn.PgError{Code: "23505"}))
+	r.False(isSerializationFailure(errors.New("40001")))
+}
+
+func Test_Connection_RetryTransaction(t *testing.T) {
+	r := require.New(t)
+	c := &Connection{Dialect: &postgresql{commonDialect: commonDialect{ConnectionDetails: &ConnectionDetails{
+		Options: map[string]string{
+			"tx_retry_attempts": "3",
+			"tx_retry_backoff":  "1ms",
+		},
+	}}}}
+	retryErr := &pgconn.PgError{Code: "40001"}
+
+	calls := 0
+	err := c.retryTransaction(nil, func() error {
+		calls++
+		if calls < 3 {
+			return retryErr
+		}
+		return nil
+	})
+	r.NoError(err)
+	r.Equal(3, calls)
+
+	calls = 0
+	err = c.retryTransaction(nil, func() error {
+		calls++
+		return retryErr
+	})
+	r.ErrorIs(err, retryErr)
+	r.Equal(3, calls)
+
+	calls = 0
+	err = c.retryTransaction(nil, func() error {
+		calls++
+		return errors.New("not retryable")
+	})
+	r.Error(err)
+	r.Equal(1, calls)
+}
@@ -0,0 +1,136 @@
+package pop
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Transaction_Nested(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		r.NoError(tx.Transaction(nil, func(tx *Connection) error {
+			return tx.Create(nil, &User{Name: nulls.NewString("Kept")})
+		}))
+
+		err := tx.Transaction(nil, func(tx *Connection) error {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Dropped")}))
+			return errors.New("inner failure")
+		})
+		r.EqualError(err, "inner failure")
+
+		r.Panics(func() {
+			_ = tx.Transaction(nil, func(tx *Connection) error {
+				r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Panicked")}))
+				panic("inner panic")
+			})
+		})
+
+		users := []User{}
+		r.NoError(tx.All(nil, &users))
+		r.Len(users, 1)
+		r.Equal("Kept", users[0].Name.String)
+	})
+}
+
+func Test_Transaction_Nested_Deep(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		err := tx.Transaction(nil, func(tx *Connection) error {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Outer")}))
+			err := tx.Transaction(nil, func(tx *Connection) error {
+				r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Inner")}))
+				return errors.New("inner failure")
+			})
+			r.Error(err)
+			return nil
+		})
+		r.NoError(err)
+
+		count, err := tx.Count(nil, &User{})
+		r.NoError(err)
+		r.Equal(1, count)
+	})
+}
+
+func Test_Rollback_Nested(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Kept")}))
+		r.NoError(tx.Rollback(nil, func(tx *Connection) {
+			r.NoError(tx.Create(nil, &User{Name: nulls.NewString("Dropped")}))
+		}))
+
+		count, err := tx.Count(nil, &User{})
+		r.NoError(err)
+		r.Equal(1, count)
+	})
+}
+
+func Test_Transaction_AfterCommit(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	var events []string
+	r.NoError(PDB.Transaction(nil, func(tx *Connection) error {
+		tx.AfterCommit(func() { events = append(events, "commit") })
+		tx.AfterRollback(func() { events = append(events, "rollback") })
+		r.Empty(events)
+		return nil
+	}))
+	r.Equal([]string{"commit"}, events)
+
+	events = nil
+	err := PDB.Transaction(nil, func(tx *Connection) error {
+		tx.AfterCommit(func() { events = append(events, "commit") })
+		tx.AfterRollback(func() { events = append(events, "rollback") })
+		return errors.New("failure")
+	})
+	r.Error(err)
+	r.Equal([]string{"rollback"}, events)
+
+	events = nil
+	PDB.AfterCommit(func() { events = append(events, "immediate") })
+	PDB.AfterRollback(func() { events = append(events, "never") })
+	r.Equal([]string{"immediate"}, events)
+}
+
+func Test_Transaction_AfterCommit_Nested(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	r := require.New(t)
+
+	var events []string
+	r.NoError(PDB.Transaction(nil, func(tx *Connection) error {
+		r.NoError(tx.Transaction(nil, func(tx *Connection) error {
+			tx.AfterCommit(func() { events = append(events, "kept") })
+			return nil
+		}))
+		err := tx.Transaction(nil, func(tx *Connection) error {
+			tx.AfterCommit(func() { events = append(events, "dropped") })
+			tx.AfterRollback(func() { events = append(events, "inner rollback") })
+			return errors.New("inner failure")
+		})
+		r.Error(err)
+		r.Equal([]string{"inner rollback"}, events)
+		return nil
+	}))
+	r.Equal([]string{"inner rollback", "kept"}, events)
+}
@@ -0,0 +1,72 @@
+package pop
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gobuffalo/flect"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+)
+
+// ValidateUnique checks that no other record of the table of model has the
+// same value in column, or the same values in column and the scope columns
+// if any are given. The record itself is excluded when model has an ID, so
+// it can be used on both create and update. The error is keyed by column.
+// The lookup runs on the primary database, even if c has read replicas.
+//
+//	func (u *User) Validate(tx *pop.Connection) (*validate.Errors, error) {
+//		return pop.ValidateUnique(tx, u, "email", "organization_id")
+//	}
+func ValidateUnique(c *Connection, model interface{}, column string, scope ...string) (*validate.Errors, error) {
+	verrs := validate.NewErrors()
+	m := NewModel(model, c.Context())
+	v := reflect.Indirect(reflect.ValueOf(model))
+
+	q := Q(c).UsePrimary()
+	for _, col := range append([]string{column}, scope...) {
+		f := fieldMapper.FieldByName(v, col)
+		if !f.IsValid() {
+			return verrs, fmt.Errorf("model %T does not have a field for column %s", model, col)
+		}
+		q.Where(fmt.Sprintf("%s.%s = ?", m.Alias(), col), f.Interface())
+	}
+	if id, err := m.fieldByName("ID"); err == nil && !IsZeroOfUnderlyingType(id.Interface()) {
+		q.Where(fmt.Sprintf("%s.%s != ?", m.Alias(), m.IDField()), m.ID())
+	}
+
+	exists, err := q.Exists(model)
+	if err != nil {
+		return verrs, err
+	}
+	if exists {
+		verrs.Add(validators.GenerateKey(column), fmt.Sprintf("%s has already been taken.", flect.Humanize(column)))
+	}
+	return verrs, nil
+}
+
+// ValidateExists checks that a record with the given ID exists in table,
+// e.g. to validate a foreign key before saving. The error is keyed by the
+// foreign key of the table, such as "organization_id" for "organizations".
+// The lookup runs on the primary database, even if c has read replicas.
+//
+//	func (u *User) Validate(tx *pop.Connection) (*validate.Errors, error) {
+//		return pop.ValidateExists(tx, "organizations", u.OrganizationID)
+//	}
+func ValidateExists(c *Connection, table string, id interface{}) (*validate.Errors, error) {
+	verrs := validate.NewErrors()
+	name := flect.Singularize(table)
+
+	exists := false
+	if id != nil && !IsZeroOfUnderlyingType(id) {
+		var err error
+		exists, err = Q(c).UsePrimary().Where("id = ?", id).Exists(table)
+		if err != nil {
+			return verrs, err
+		}
+	}
+	if !exists {
+		verrs.Add(validators.GenerateKey(name+"_id"), fmt.Sprintf("%s does not exist.", flect.Humanize(name)))
+	}
+	return verrs, nil
+}
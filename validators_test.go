@@ -0,0 +1,66 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateUnique(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		s := &Subscription{Email: "mark@example.com", Plan: "free"}
+		r.NoError(tx.Create(nil, s))
+
+		// the record itself is excluded
+		verrs, err := ValidateUnique(tx, s, "email")
+		r.NoError(err)
+		r.False(verrs.HasAny())
+
+		other := &Subscription{Email: "mark@example.com", Plan: "gold"}
+		verrs, err = ValidateUnique(tx, other, "email")
+		r.NoError(err)
+		r.Equal([]string{"Email has already been taken."}, verrs.Get("email"))
+
+		verrs, err = ValidateUnique(tx, other, "email", "plan")
+		r.NoError(err)
+		r.False(verrs.HasAny())
+
+		other.Plan = "free"
+		verrs, err = ValidateUnique(tx, other, "email", "plan")
+		r.NoError(err)
+		r.True(verrs.HasAny())
+
+		_, err = ValidateUnique(tx, other, "unknown")
+		r.Error(err)
+	})
+}
+
+func Test_ValidateExists(t *testing.T) {
+	if PDB == nil {
+		t.Skip("skipping integration tests")
+	}
+	transaction(func(tx *Connection) {
+		r := require.New(t)
+
+		u := &User{Name: nulls.NewString("Mark")}
+		r.NoError(tx.Create(nil, u))
+
+		verrs, err := ValidateExists(tx, "users", u.ID)
+		r.NoError(err)
+		r.False(verrs.HasAny())
+
+		verrs, err = ValidateExists(tx, "users", u.ID+1)
+		r.NoError(err)
+		r.Equal([]string{"User does not exist."}, verrs.Get("user_id"))
+
+		verrs, err = ValidateExists(tx, "users", nulls.Int{})
+		r.NoError(err)
+		r.True(verrs.HasAny())
+	})
+}